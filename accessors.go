@@ -0,0 +1,256 @@
+package xconnect
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MustFloat same as FindFloat but panics if not found. E.g xconnect/connect/db/load-factor
+func (d Document) MustFloat(path string) float64 {
+	if v, err := d.FindFloat(path); err != nil {
+		panic(err)
+	} else {
+		return v
+	}
+}
+
+// FindFloat returns a float for a given slash path, e.g xconnect/connect/db/load-factor .
+func (d Document) FindFloat(path string) (float64, error) {
+	return FindFloat(d, path)
+}
+
+// FloatOr returns a float for a given slash path or the default value if not found or not convertible.
+func (d Document) FloatOr(path string, defaultValue float64) float64 {
+	if v, err := d.FindFloat(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// FindFloat returns a float for a given slash path.
+func FindFloat(f finder, path string) (float64, error) {
+	v, err := lookup(f, path, "float")
+	if err != nil {
+		return 0, err
+	}
+	if c, ok := toFloat(v); !ok {
+		return 0, mismatch(f, path, "float", v)
+	} else {
+		return c, nil
+	}
+}
+
+// MustDuration same as FindDuration but panics if not found. E.g xconnect/connect/db/timeout
+func (d Document) MustDuration(path string) time.Duration {
+	if v, err := d.FindDuration(path); err != nil {
+		panic(err)
+	} else {
+		return v
+	}
+}
+
+// FindDuration returns a duration for a given slash path, e.g xconnect/connect/db/timeout .
+func (d Document) FindDuration(path string) (time.Duration, error) {
+	return FindDuration(d, path)
+}
+
+// DurationOr returns a duration for a given slash path or the default value if not found or not convertible.
+func (d Document) DurationOr(path string, defaultValue time.Duration) time.Duration {
+	if v, err := d.FindDuration(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// FindDuration returns a duration for a given slash path.
+// The value is either a string such as "1m30s" or an integer number of milliseconds.
+func FindDuration(f finder, path string) (time.Duration, error) {
+	v, err := lookup(f, path, "duration")
+	if err != nil {
+		return 0, err
+	}
+	if c, ok := toDuration(v); !ok {
+		return 0, mismatch(f, path, "duration", v)
+	} else {
+		return c, nil
+	}
+}
+
+// MustStringSlice same as FindStringSlice but panics if not found. E.g xconnect/connect/events/topics
+func (d Document) MustStringSlice(path string) []string {
+	if v, err := d.FindStringSlice(path); err != nil {
+		panic(err)
+	} else {
+		return v
+	}
+}
+
+// FindStringSlice returns a list of strings for a given slash path, e.g xconnect/connect/events/topics .
+func (d Document) FindStringSlice(path string) ([]string, error) {
+	return FindStringSlice(d, path)
+}
+
+// StringSliceOr returns a list of strings for a given slash path or the default value if not found or not convertible.
+func (d Document) StringSliceOr(path string, defaultValue []string) []string {
+	if v, err := d.FindStringSlice(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// FindStringSlice returns a list of strings for a given slash path.
+// The value is either a list of scalars or a comma separated string.
+func FindStringSlice(f finder, path string) ([]string, error) {
+	v, err := lookup(f, path, "string list")
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := toStringSlice(v); !ok {
+		return nil, mismatch(f, path, "string list", v)
+	} else {
+		return c, nil
+	}
+}
+
+// MustStringMap same as FindStringMap but panics if not found. E.g xconnect/connect/db/params
+func (d Document) MustStringMap(path string) map[string]string {
+	if v, err := d.FindStringMap(path); err != nil {
+		panic(err)
+	} else {
+		return v
+	}
+}
+
+// FindStringMap returns a map of strings for a given slash path, e.g xconnect/connect/db/params .
+func (d Document) FindStringMap(path string) (map[string]string, error) {
+	return FindStringMap(d, path)
+}
+
+// StringMapOr returns a map of strings for a given slash path or the default value if not found or not convertible.
+func (d Document) StringMapOr(path string, defaultValue map[string]string) map[string]string {
+	if v, err := d.FindStringMap(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// FindStringMap returns a map of strings for a given slash path.
+func FindStringMap(f finder, path string) (map[string]string, error) {
+	v, err := lookup(f, path, "string map")
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := toStringMap(v); !ok {
+		return nil, mismatch(f, path, "string map", v)
+	} else {
+		return c, nil
+	}
+}
+
+// MustURL same as FindURL but panics if not found. E.g xconnect/connect/api/url
+func (d Document) MustURL(path string) *url.URL {
+	if v, err := d.FindURL(path); err != nil {
+		panic(err)
+	} else {
+		return v
+	}
+}
+
+// FindURL returns a parsed URL for a given slash path, e.g xconnect/connect/api/url .
+func (d Document) FindURL(path string) (*url.URL, error) {
+	return FindURL(d, path)
+}
+
+// URLOr returns a parsed URL for a given slash path or the default value if not found or not convertible.
+func (d Document) URLOr(path string, defaultValue *url.URL) *url.URL {
+	if v, err := d.FindURL(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// FindURL returns a parsed URL for a given slash path.
+func FindURL(f finder, path string) (*url.URL, error) {
+	v, err := lookup(f, path, "url")
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := toURL(v); !ok {
+		return nil, mismatch(f, path, "url", v)
+	} else {
+		return c, nil
+	}
+}
+
+// MustTime same as FindTime but panics if not found. E.g xconnect/meta/released
+func (d Document) MustTime(path string) time.Time {
+	if v, err := d.FindTime(path); err != nil {
+		panic(err)
+	} else {
+		return v
+	}
+}
+
+// FindTime returns a time for a given slash path, e.g xconnect/meta/released .
+func (d Document) FindTime(path string) (time.Time, error) {
+	return FindTime(d, path)
+}
+
+// TimeOr returns a time for a given slash path or the default value if not found or not convertible.
+func (d Document) TimeOr(path string, defaultValue time.Time) time.Time {
+	if v, err := d.FindTime(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// FindTime returns a time for a given slash path.
+// The value is either a YAML timestamp, a RFC3339 string or a date such as "2006-01-02".
+func FindTime(f finder, path string) (time.Time, error) {
+	v, err := lookup(f, path, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if c, ok := toTime(v); !ok {
+		return time.Time{}, mismatch(f, path, "time", v)
+	} else {
+		return c, nil
+	}
+}
+
+// Decode finds the subtree at a slash path and unmarshals it into v, which must be a non-nil pointer.
+// E.g. xconnect/connect/variant-pull/resource/test .
+func (d Document) Decode(path string, v interface{}) error {
+	return Decode(d, path, v)
+}
+
+// Decode finds the subtree at a slash path and unmarshals it into v, which must be a non-nil pointer.
+func Decode(f finder, path string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("xconnect: Decode requires a non-nil pointer")
+	}
+	found, err := lookup(f, path, "value")
+	if err != nil {
+		return err
+	}
+	// use the value itself if possible ; not all values survive a YAML round trip
+	if fv := reflect.ValueOf(found); fv.IsValid() && fv.Type().AssignableTo(rv.Elem().Type()) {
+		rv.Elem().Set(fv)
+		return nil
+	}
+	// secrets are marshalled as their reference
+	data, err := yaml.Marshal(revealSecrets(found))
+	if err != nil {
+		return fmt.Errorf("unable to marshal value at [%s]:%v", path, err)
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to decode value at [%s]:%v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package xconnect
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestCoercion(t *testing.T) {
+	doc, err := LoadConfig("testdata/accessors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustInt("xconnect/listen/api/admin-port"), 9090; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustBool("xconnect/listen/api/public"), true; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustString("xconnect/connect/events/partitions"), "3"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustFloat("xconnect/connect/events/partitions"), 3.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, err := doc.FindInt("xconnect/connect/events/factor"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	doc, err := LoadConfig("testdata/accessors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustFloat("xconnect/connect/events/factor"), 0.75; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustDuration("xconnect/connect/events/timeout"), 2*time.Second; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustDuration("xconnect/connect/events/slow"), 1500*time.Millisecond; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustStringSlice("xconnect/connect/events/topics"), []string{"a", "b"}; len(got) != 2 || got[1] != want[1] {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustStringSlice("xconnect/connect/events/csv"), []string{"x", "y", "z"}; len(got) != 3 || got[1] != want[1] {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustStringSlice("xconnect/meta/tags"), []string{"one", "two"}; len(got) != 2 || got[0] != want[0] {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	m := doc.MustStringMap("xconnect/connect/events/params")
+	if got, want := m["sslmode"], "disable"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := m["retries"], "3"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	u := doc.MustURL("xconnect/connect/events/url")
+	if got, want := u.Port(), "8443"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustTime("xconnect/connect/events/since").Hour(), 10; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustTime("xconnect/meta/released").Month(), time.March; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestAccessorsOr(t *testing.T) {
+	doc, err := LoadConfig("testdata/accessors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.DurationOr("xconnect/connect/events/missing", time.Minute), time.Minute; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.IntOr("xconnect/connect/events/partitions", 1), 3; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.StringOr("xconnect/connect/missing/url", "none"), "none"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.BoolOr("xconnect/listen/api/missing", true), true; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(doc.StringSliceOr("xconnect/connect/events/params", nil)), 0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.FloatOr("xconnect/connect/events/kind", 1.5), 1.5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := doc.URLOr("xconnect/connect/events/missing", nil); got != nil {
+		t.Errorf("got [%v] want nil", got)
+	}
+	if got := doc.TimeOr("xconnect/connect/events/missing", time.Time{}); !got.IsZero() {
+		t.Errorf("got [%v] want zero", got)
+	}
+	if got := doc.StringMapOr("xconnect/connect/events/missing", map[string]string{"a": "b"}); got["a"] != "b" {
+		t.Errorf("got [%v]", got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	cfg := `
+xconnect:
+  connect:
+    variant-pull:
+      kind: gcp.pubsub
+      resource: Variant_v1-subscription
+      test:
+        topic: Variant_v1-topic
+        partitions: 2
+`
+	var doc Document
+	if err := yaml.Unmarshal([]byte(cfg), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var test struct {
+		Topic      string `yaml:"topic"`
+		Partitions int    `yaml:"partitions"`
+	}
+	if err := doc.Decode("xconnect/connect/variant-pull/test", &test); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := test.Topic, "Variant_v1-topic"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := test.Partitions, 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	var entry ConnectEntry
+	if err := doc.Decode("xconnect/connect/variant-pull", &entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := entry.Kind, "gcp.pubsub"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	var all map[string]ConnectEntry
+	if err := doc.Decode("xconnect/connect", &all); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(all), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if err := doc.Decode("xconnect/connect/missing", &entry); err == nil {
+		t.Error("error expected")
+	}
+	if err := doc.Decode("xconnect/connect/variant-pull", entry); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,238 @@
+package xconnect
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// keepAliveField is the name of the extra field of a connect entry with the TCP keep-alive period.
+const keepAliveField = "keep-alive"
+
+// dialer returns a net.Dialer with the connect timeout and keep-alive of this entry.
+func (e ConnectEntry) dialer() *net.Dialer {
+	d := &net.Dialer{Timeout: e.Tuning().ConnectTimeout}
+	if t, err := FindDuration(e, keepAliveField); err == nil {
+		d.KeepAlive = t
+	}
+	return d
+}
+
+// Dial opens a TCP connection to the host and port, or the URL, of this entry.
+// If secure is true then the connection uses TLS as configured by tls-config.
+// The connect timeout and retry policy of Tuning() and the extra field keep-alive are honored.
+func (e ConnectEntry) Dial(ctx context.Context) (net.Conn, error) {
+	if e.Disabled {
+		return nil, ErrDisabled
+	}
+	ep := e.Endpoint()
+	if ep.IsZero() || ep.Port == 0 {
+		return nil, fmt.Errorf("unable to dial, unknown host or port of [%s]", e.ResourceID())
+	}
+	var tc *tls.Config
+	if e.EffectiveSecure() {
+		c, err := e.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		tc = c
+	}
+	d := e.dialer()
+	t := e.Tuning()
+	var conn net.Conn
+	err := withRetries(ctx, t.retries(), t.RetryBackoff, func() (err error) {
+		conn, err = d.DialContext(ctx, "tcp", ep.Address())
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tc == nil {
+		return conn, nil
+	}
+	client := tls.Client(conn, tc)
+	// the connect timeout also limits the handshake ; a server that accepts but never answers would block forever
+	deadline, ok := ctx.Deadline()
+	if t.ConnectTimeout > 0 {
+		if d := time.Now().Add(t.ConnectTimeout); !ok || d.Before(deadline) {
+			deadline, ok = d, true
+		}
+	}
+	if ok {
+		client.SetDeadline(deadline)
+		defer client.SetDeadline(time.Time{})
+	}
+	if err := client.Handshake(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with [%s] failed:%v", ep.Address(), err)
+	}
+	return client, nil
+}
+
+// withRetries calls f until it succeeds, with at most retries extra attempts.
+// The wait time before the first retry is backoff ; it doubles for each next retry.
+func withRetries(ctx context.Context, retries int, backoff time.Duration, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// HTTPClient is a http.Client for the base URL of a connect entry.
+type HTTPClient struct {
+	*http.Client
+	BaseURL *url.URL
+}
+
+// HTTPClient returns a client for the URL, or for the host and port, of this entry.
+// If secure is true then the base URL uses https, also for a http url, and TLS is configured by tls-config.
+// The values of Tuning() and the extra field keep-alive are honored ; there is no request timeout unless one is set for this entry.
+func (e ConnectEntry) HTTPClient() (*HTTPClient, error) {
+	if e.Disabled {
+		return nil, ErrDisabled
+	}
+	base, err := e.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	tc, err := e.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	t := e.Tuning()
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		DialContext:     e.dialer().DialContext,
+		TLSClientConfig: tc,
+		MaxConnsPerHost: t.MaxConnections,
+		IdleConnTimeout: t.IdleTimeout,
+	}
+	client := &http.Client{Transport: retryTransport{next: transport, retries: t.retries(), backoff: t.RetryBackoff}}
+	if e.RequestTimeout != nil {
+		client.Timeout = time.Duration(*e.RequestTimeout)
+	}
+	return &HTTPClient{Client: client, BaseURL: base}, nil
+}
+
+func (e ConnectEntry) baseURL() (*url.URL, error) {
+	if len(e.URL) != 0 {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url [%s]:%v", e.URL, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("url [%s] is not http or https", e.URL)
+		}
+		if e.EffectiveSecure() {
+			u.Scheme = "https"
+		}
+		return u, nil
+	}
+	ep := e.Endpoint()
+	if ep.IsZero() {
+		return nil, fmt.Errorf("unable to create client, unknown host of [%s]", e.ResourceID())
+	}
+	scheme := "http"
+	if e.EffectiveSecure() {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: ep.Address()}, nil
+}
+
+// URL returns the absolute URL for a path relative to the base URL.
+func (c *HTTPClient) URL(path string) string {
+	rel, err := url.Parse(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return c.BaseURL.String() + path
+	}
+	base := *c.BaseURL
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	return base.ResolveReference(rel).String()
+}
+
+// NewRequest returns a request for a path relative to the base URL.
+func (c *HTTPClient) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.URL(path), body)
+	if err != nil {
+		return nil, err
+	}
+	return req.WithContext(ctx), nil
+}
+
+// Get issues a GET for a path relative to the base URL.
+func (c *HTTPClient) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// retryTransport retries idempotent requests that fail with a network error or a 502, 503 or 504 status.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+// RoundTrip is part of http.RoundTripper
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.retries == 0 || !isIdempotent(req) {
+		return t.next.RoundTrip(req)
+	}
+	var resp *http.Response
+	err := withRetries(req.Context(), t.retries, t.backoff, func() error {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		attempt := req
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			attempt = req.Clone(req.Context())
+			attempt.Body = body
+		}
+		var err error
+		resp, err = t.next.RoundTrip(attempt)
+		if err != nil {
+			resp = nil
+			return err
+		}
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	})
+	if resp != nil {
+		// the last response is returned, also if its status is a retryable one
+		return resp, nil
+	}
+	return nil, err
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package xconnect
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			c.Write([]byte("hello"))
+			c.Close()
+		}
+	}()
+	port := l.Addr().(*net.TCPAddr).Port
+	timeout := Duration(time.Second)
+	e := ConnectEntry{Protocol: "tcp", Host: "127.0.0.1", Port: &port, ConnectTimeout: &timeout}
+	conn, err := e.Dial(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	data, _ := ioutil.ReadAll(conn)
+	if got, want := string(data), "hello"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestDialSecure(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	host, port := splitTestURL(t, srv.URL)
+	// trust the certificate of the test server
+	dir, err := ioutil.TempDir("", "xconnect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := filepath.Join(dir, "ca.crt")
+	if err := ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	yes := true
+	e := ConnectEntry{Host: host, Port: &port, Secure: &yes, TLS: &TLS{CAFile: ca}}
+	conn, err := e.Dial(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		t.Fatalf("got [%T] want *tls.Conn", conn)
+	}
+	if got, want := tc.ConnectionState().HandshakeComplete, true; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestDialHandshakeTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// accept but never answer the handshake
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			time.Sleep(2 * time.Second)
+			c.Close()
+		}
+	}()
+	port := l.Addr().(*net.TCPAddr).Port
+	yes := true
+	timeout := Duration(100 * time.Millisecond)
+	e := ConnectEntry{Protocol: "tcp", Host: "127.0.0.1", Port: &port, Secure: &yes, ConnectTimeout: &timeout}
+	start := time.Now()
+	if _, err := e.Dial(context.Background()); err == nil {
+		t.Fatal("error expected")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got [%v] want less than [1s]", elapsed)
+	}
+}
+
+func TestDialErrors(t *testing.T) {
+	if _, err := (ConnectEntry{Kind: "db"}).Dial(context.Background()); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := (ConnectEntry{Host: "localhost", Disabled: true}).Dial(context.Background()); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := (ConnectEntry{Host: "localhost", Disabled: true}).HTTPClient(); err != ErrDisabled {
+		t.Errorf("got [%v] want [%v]", err, ErrDisabled)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+	timeout := Duration(2 * time.Second)
+	e := ConnectEntry{URL: srv.URL + "/api", RequestTimeout: &timeout, ExtraFields: map[string]interface{}{
+		"retries": 2,
+	}}
+	c, err := e.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Timeout, 2*time.Second; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	resp, err := c.Get(context.Background(), "/accounts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	if got, want := string(data), "/api/accounts/1"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := atomic.LoadInt32(&calls), int32(3); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestHTTPClientBaseURL(t *testing.T) {
+	port := 8443
+	yes := true
+	c, err := ConnectEntry{Protocol: "http", Host: "api.net", Port: &port, Secure: &yes}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.URL("v1/accounts"), "https://api.net:8443/v1/accounts"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	c, err = ConnectEntry{URL: "http://api.net:8443/v1", Secure: &yes}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.URL("accounts"), "https://api.net:8443/v1/accounts"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, err := (ConnectEntry{URL: "jdbc:postgresql://db/x"}).HTTPClient(); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func splitTestURL(t *testing.T, rawURL string) (string, int) {
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(strings.TrimPrefix(rawURL, "https://"), "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
@@ -2,15 +2,14 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/emicklei/dot"
 	"github.com/emicklei/xconnect"
-	"gopkg.in/yaml.v2"
 )
 
 // read all xconnect config files
@@ -19,6 +18,14 @@ import (
 var master = dot.NewGraph(dot.Directed)
 var networkIDtoNode = map[string]dot.Node{}
 
+// listenNodes are used to match connect entries by their normalized endpoint.
+var listenNodes = []listenNode{}
+
+type listenNode struct {
+	endpoint xconnect.Endpoint
+	node     dot.Node
+}
+
 // xconnect -dot | dot -Tpng  > graph.png && open graph.png
 
 func makeGraph() {
@@ -26,10 +33,11 @@ func makeGraph() {
 	for _, each := range collectYAMLnames() {
 		d, err := loadDocument(each)
 		if err != nil {
-			log.Println(err)
+			log.Println(xconnect.Redact(err.Error()))
 		}
 		//fmt.Println("loaded", d.Config.Meta.Name)
-		cfgs = append(cfgs, d.XConnect)
+		// mask credentials, e.g. in URLs that end up in node identifiers
+		cfgs = append(cfgs, d.XConnect.Redacted())
 	}
 	for _, each := range cfgs {
 		addToGraph(each, master)
@@ -37,6 +45,16 @@ func makeGraph() {
 	for _, each := range cfgs {
 		connectInGraph(each, master)
 	}
+	for _, each := range xconnect.ValidateConnections(cfgs...) {
+		fmt.Fprintf(os.Stderr, "[xconnect] %s\n", each)
+	}
+	if *oStrict {
+		for _, cfg := range cfgs {
+			for _, each := range cfg.CheckExtraFields() {
+				fmt.Fprintf(os.Stderr, "[xconnect] %s\n", each)
+			}
+		}
+	}
 	fmt.Println(master.String())
 }
 
@@ -55,15 +73,20 @@ func collectYAMLnames() (files []string) {
 }
 
 func loadDocument(name string) (xconnect.Document, error) {
-	content, err := ioutil.ReadFile(name)
-	if err != nil {
-		return xconnect.Document{}, err
-	}
-	var d xconnect.Document
-	if err = yaml.Unmarshal(content, &d); err != nil {
-		return xconnect.Document{}, err
+	// a loader keeps the source positions ; secrets and the environment of the service are not needed for a graph
+	loader := xconnect.NewLoader()
+	loader.SkipSecrets = true
+	loader.KeepUnresolved = true
+	loader.LookupEnv = xconnect.NoEnv
+	return loader.Load(name)
+}
+
+// sourceOf returns the source position of a path in the section, e.g. listen/api, or an empty string if unknown.
+func sourceOf(cfg xconnect.XConnect, path string) string {
+	if p, ok := cfg.Position(path); ok {
+		return p.String()
 	}
-	return d, nil
+	return ""
 }
 
 func addToGraph(cfg xconnect.XConnect, g *dot.Graph) {
@@ -77,15 +100,30 @@ func addToGraph(cfg xconnect.XConnect, g *dot.Graph) {
 		id := fmt.Sprintf("%s/%s", cfg.Meta.Name, k)
 		n := s.Node(id).Label(k)
 		n.Attr("fillcolor", "#FFFFFF").Attr("style", "filled")
+		if src := sourceOf(cfg, "listen/"+k); src != "" {
+			n.Attr("tooltip", src)
+		}
 		if bg, ok := v.ExtraFields["ui-fillcolor"]; ok {
 			n.Attr("fillcolor", bg).Attr("style", "filled")
 		}
-		networkIDtoNode[v.NetworkID()] = n
+		if id := v.NetworkID(); id != "" {
+			networkIDtoNode[id] = n
+		}
+		if ep := v.Endpoint(); !ep.IsZero() {
+			listenNodes = append(listenNodes, listenNode{endpoint: ep, node: n})
+		}
+		// other services can connect to the resource, e.g. publish to a topic
+		if v.Kind != "" {
+			networkIDtoNode[kindResourceID(v.Kind, v.Resource)] = n
+		}
 	}
 	for k := range cfg.Connect {
 		id := fmt.Sprintf("%s/%s", cfg.Meta.Name, k)
 		// https://graphviz.org/doc/info/shapes.html#polygon
-		s.Node(id).Label(k).Attr("shape", "plaintext")
+		n := s.Node(id).Label(k).Attr("shape", "plaintext")
+		if src := sourceOf(cfg, "connect/"+k); src != "" {
+			n.Attr("tooltip", src)
+		}
 	}
 
 }
@@ -96,6 +134,22 @@ func connectInGraph(cfg xconnect.XConnect, g *dot.Graph) {
 		id := fmt.Sprintf("%s/%s", cfg.Meta.Name, k)
 		from := s.Node(id)
 		to, ok := networkIDtoNode[v.NetworkID()]
+		if !ok {
+			to, ok = findListenNode(v.Endpoint())
+		}
+		if !ok && v.Kind != "" {
+			to, ok = networkIDtoNode[kindResourceID(v.Kind, v.Resource)]
+		}
+		if !ok && v.URL != "" {
+			// services that connect to the same host share a node, e.g. a database server
+			if cs, err := v.ParseURL(); err == nil {
+				id := hostNodeID(cs)
+				if to, ok = networkIDtoNode[id]; !ok {
+					to, ok = g.Node(id), true
+					networkIDtoNode[id] = to
+				}
+			}
+		}
 		if !ok {
 			// if kind is set then create a node to represent the other end
 			if v.Kind != "" {
@@ -104,10 +158,72 @@ func connectInGraph(cfg xconnect.XConnect, g *dot.Graph) {
 				// remember
 				networkIDtoNode[id] = to
 			} else {
-				fmt.Fprintf(os.Stderr, "[xconnect] no listen entry found: %s\n", v.NetworkID())
+				if src := sourceOf(cfg, "connect/"+k); src != "" {
+					fmt.Fprintf(os.Stderr, "[xconnect] %s: no listen entry found: %s\n", src, v.NetworkID())
+				} else {
+					fmt.Fprintf(os.Stderr, "[xconnect] no listen entry found: %s\n", v.NetworkID())
+				}
 				continue
 			}
 		}
-		from.Edge(to).Attr("arrowtail", "dot").Attr("dir", "both")
+		tooltip := tuningTooltip(v)
+		if src := sourceOf(cfg, "connect/"+k); src != "" {
+			tooltip = src + "\n" + tooltip
+		}
+		from.Edge(to).Attr("arrowtail", "dot").Attr("dir", "both").Attr("tooltip", tooltip)
+	}
+}
+
+// tuningTooltip returns the tuning values of a connect entry ; values that are not in the document are marked as default.
+func tuningTooltip(e xconnect.ConnectEntry) string {
+	t := e.Tuning()
+	lines := []string{}
+	if !e.HasTimeout() {
+		lines = append(lines, "no timeout configured")
+	}
+	duration := func(d time.Duration, set bool) string {
+		s := "none"
+		if d != 0 {
+			s = d.String()
+		}
+		if !set {
+			s += " (default)"
+		}
+		return s
+	}
+	lines = append(lines,
+		"connect-timeout: "+duration(t.ConnectTimeout, e.ConnectTimeout != nil),
+		"request-timeout: "+duration(t.RequestTimeout, e.RequestTimeout != nil),
+		"idle-timeout: "+duration(t.IdleTimeout, e.IdleTimeout != nil))
+	if t.RetryAttempts > 1 {
+		lines = append(lines, fmt.Sprintf("retry: %d attempts, backoff %s", t.RetryAttempts, t.RetryBackoff))
+	}
+	if t.MaxConnections > 0 {
+		lines = append(lines, fmt.Sprintf("max-connections: %d", t.MaxConnections))
 	}
+	return strings.Join(lines, "\n")
+}
+
+// findListenNode returns the node of the listen entry that matches the endpoint.
+func findListenNode(ep xconnect.Endpoint) (dot.Node, bool) {
+	for _, each := range listenNodes {
+		if each.endpoint.Matches(ep) {
+			return each.node, true
+		}
+	}
+	return dot.Node{}, false
+}
+
+// hostNodeID returns SCHEME://HOST:PORT of the first host, without credentials or database.
+func hostNodeID(cs xconnect.ConnectionString) string {
+	scheme := cs.Driver
+	if scheme == "" {
+		scheme = cs.Scheme
+	}
+	return fmt.Sprintf("%s://%s", scheme, cs.Hosts[0].Address())
+}
+
+// kindResourceID returns the KIND:RESOURCE identifier as used by ResourceID().
+func kindResourceID(kind, resource string) string {
+	return fmt.Sprintf("%s:%s", kind, resource)
 }
@@ -12,13 +12,31 @@ import (
 	"strings"
 
 	"github.com/emicklei/xconnect"
-	"gopkg.in/yaml.v2"
 )
 
 var oDot = flag.Bool("dot", false, "generate a DOT file")
 var oInput = flag.String("input", "", "name of the YAML configuration file that contains a xconnect section")
 var oK8S = flag.Bool("k8s", false, "YAML is a Kubernetes configuration file with data:xconnect section")
 var oTarget = flag.String("target", "", "destination for the JSON representation of the xconnect configuration, http or file scheme")
+var oStrict = flag.Bool("strict", false, "report extra fields of listen and connect entries that look like misspellings or legacy names of known fields")
+var oKey = flag.String("key", os.Getenv(xconnect.KeyFileEnv), "name of the key file for encrypt and decrypt")
+var oOverlays stringList
+var oFields stringList
+
+func init() {
+	flag.Var(&oOverlays, "overlay", "name of a YAML file that is merged on top of the input, can be repeated")
+	flag.Var(&oFields, "field", "name of a field to encrypt in addition to fields with sensitive names, can be repeated")
+}
+
+// stringList is a flag.Value that collects all occurrences of a flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
 
 func main() {
 	flag.Parse()
@@ -28,40 +46,63 @@ func main() {
 		os.Exit(1)
 	}
 
+	if flag.Arg(0) == "schema" {
+		os.Stdout.Write(xconnect.JSONSchemaBytes())
+		return
+	}
+
+	if flag.Arg(0) == "encrypt" || flag.Arg(0) == "decrypt" {
+		if err := cryptFile(flag.Arg(0), *oInput, *oKey); err != nil {
+			log.Fatal("[xconnect] ", err)
+		}
+		return
+	}
+
 	if *oDot {
 		makeGraph()
 		return
 	}
 
 	log.Printf("[xconnect] reading [%s]\n", *oInput)
-	content, err := ioutil.ReadFile(*oInput)
-	if err != nil {
-		log.Fatal(err)
-	}
 	var cfg xconnect.XConnect
 	if *oK8S { // get xconnect section from k8s configuration
-		extracted, err := readK8S(content)
+		if len(oOverlays) > 0 {
+			log.Fatal("[xconnect] -overlay cannot be used with -k8s")
+		}
+		extracted, err := readK8S(*oInput)
 		if err != nil {
-			log.Fatal(err)
+			log.Fatal(xconnect.Redact(err.Error()))
 		}
 		cfg = extracted
 	} else {
-		extracted, err := readXConnectDocument(content)
+		extracted, err := readXConnectDocument(*oInput, oOverlays)
 		if err != nil {
-			log.Fatal(err)
+			log.Fatal(xconnect.Redact(err.Error()))
 		}
 		cfg = extracted
 	}
+	// mask credentials before anything is written
+	cfg = cfg.Redacted()
+	findings := cfg.Validate()
+	if *oStrict {
+		findings = append(findings, cfg.CheckExtraFields()...)
+	}
+	for _, each := range findings {
+		log.Println("[xconnect]", each)
+	}
+	if xconnect.HasErrors(findings) {
+		log.Fatalf("[xconnect] invalid configuration [%s]", *oInput)
+	}
 	var buf bytes.Buffer
 	enc := json.NewEncoder(&buf)
 	if err := enc.Encode(xconnect.Document{XConnect: cfg}); err != nil {
 		log.Fatal("unable to marshal into JSON", err)
 	}
 	if strings.HasPrefix(*oTarget, "http") {
-		log.Println("POST", *oTarget)
+		log.Println("POST", xconnect.Redact(*oTarget))
 		resp, err := http.Post(*oTarget, "image/jpeg", &buf)
 		if err != nil {
-			log.Fatal("unable to POST configuration", err)
+			log.Fatal("unable to POST configuration ", xconnect.Redact(err.Error()))
 		}
 		if resp.StatusCode != http.StatusOK {
 			log.Fatal("unable to POST configuration", err)
@@ -80,24 +121,63 @@ func main() {
 	log.Println("[xconnect] OK")
 }
 
-func readXConnectDocument(content []byte) (cfg xconnect.XConnect, err error) {
-	log.Println("[xconnect] parse xconnect configuration", *oInput)
-	var d xconnect.Document
-	if err = yaml.Unmarshal(content, &d); err != nil {
+func readXConnectDocument(filename string, overlays []string) (cfg xconnect.XConnect, err error) {
+	log.Println("[xconnect] parse xconnect configuration", filename, strings.Join(overlays, " "))
+	// secrets and the environment of this machine are not needed and must not be sent to the target
+	loader := xconnect.NewLoader()
+	loader.SkipSecrets = true
+	loader.KeepUnresolved = true
+	loader.LookupEnv = xconnect.NoEnv
+	d, err := loader.Load(filename, overlays...)
+	if err != nil {
 		return
 	}
 	return d.XConnect, nil
 }
 
-func readK8S(content []byte) (cfg xconnect.XConnect, err error) {
-	log.Println("PARSE Kubernetes (k8s) Configuration", *oInput)
-	var k xconnect.K8SConfiguration
-	if err = yaml.Unmarshal(content, &k); err != nil {
+func readK8S(filename string) (cfg xconnect.XConnect, err error) {
+	log.Println("PARSE Kubernetes (k8s) Configuration", filename)
+	// positions refer to the ConfigMap file ; secrets and the environment of the service are not needed
+	loader := xconnect.NewLoader()
+	loader.SkipSecrets = true
+	loader.KeepUnresolved = true
+	loader.LookupEnv = xconnect.NoEnv
+	d, err := loader.LoadK8S(filename)
+	if err != nil {
 		return
 	}
-	x, err := k.ExtractConfig()
+	return d.XConnect, nil
+}
+
+// cryptFile encrypts or decrypts the values of the input file in place.
+func cryptFile(action, filename, keyFile string) error {
+	if keyFile == "" {
+		return fmt.Errorf("missing -key or %s", xconnect.KeyFileEnv)
+	}
+	key, err := xconnect.ReadKeyFile(keyFile)
 	if err != nil {
-		return
+		return err
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var n int
+	if action == "encrypt" {
+		content, n, err = xconnect.EncryptYAML(content, key, oFields...)
+	} else {
+		content, n, err = xconnect.DecryptYAML(content, key)
+	}
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filename, content, info.Mode()); err != nil {
+		return err
 	}
-	return x, nil
+	log.Printf("[xconnect] %sed %d values in [%s]\n", action, n, filename)
+	return nil
 }
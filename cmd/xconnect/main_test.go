@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadXConnectDocumentUnresolved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xconnect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "application.yml")
+	if err := ioutil.WriteFile(file, []byte(`spring:
+  datasource:
+    password: ${DB_PASSWORD}
+xconnect:
+  meta:
+    name: shop
+  connect:
+    db:
+      host: ${XCONNECT_TEST_HOST}
+      password: ${DB_PASSWORD}
+`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// the environment of this machine must not end up in the document
+	os.Setenv("XCONNECT_TEST_HOST", "ci-host")
+	defer os.Unsetenv("XCONNECT_TEST_HOST")
+	cfg, err := readXConnectDocument(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.Connect["db"].Host, "${XCONNECT_TEST_HOST}"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := cfg.Connect["db"].ExtraFields["password"], "${DB_PASSWORD}"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+package xconnect
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The functions in this file convert values, as decoded from YAML or JSON, into Go types.
+// Quoted scalars are accepted too, e.g. "8080" for an int and "true" for a bool.
+
+// toInt returns the integer value of a number that has no fraction or of a string holding one.
+func toInt(v interface{}) (int, bool) {
+	switch t := v.(type) {
+	case int:
+		return t, true
+	case int64:
+		return int(t), true
+	case uint64:
+		return int(t), true
+	case float64:
+		if t == float64(int(t)) {
+			return int(t), true
+		}
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(t))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// toFloat returns the float value of any number or of a string holding one.
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toBool returns the bool value of a bool or of a string holding one.
+func toBool(v interface{}) (bool, bool) {
+	switch t := v.(type) {
+	case bool:
+		return t, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "true", "yes", "on", "1":
+			return true, true
+		case "false", "no", "off", "0":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// toString returns the string value of a string, the value of a Secret or the formatted value of a number or bool.
+func toString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case Secret:
+		return t.value, true
+	case int, int64, uint64, bool:
+		return fmt.Sprint(t), true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// toDuration returns the duration of a string such as "1m30s".
+// Integer values are interpreted as milliseconds.
+func toDuration(v interface{}) (time.Duration, bool) {
+	switch t := v.(type) {
+	case time.Duration:
+		return t, true
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(t))
+		return d, err == nil
+	}
+	if i, ok := toInt(v); ok {
+		return time.Duration(i) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// toStringSlice returns the strings of a list of scalars or of a comma separated string.
+func toStringSlice(v interface{}) ([]string, bool) {
+	switch t := v.(type) {
+	case []string:
+		return t, true
+	case []interface{}:
+		list := make([]string, 0, len(t))
+		for _, each := range t {
+			s, ok := toString(each)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, s)
+		}
+		return list, true
+	case string:
+		list := []string{}
+		for _, each := range strings.Split(t, ",") {
+			if s := strings.TrimSpace(each); len(s) > 0 {
+				list = append(list, s)
+			}
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// toStringMap returns the string values of a map of scalars.
+func toStringMap(v interface{}) (map[string]string, bool) {
+	m, ok := normalizeKeys(v).(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	sm := make(map[string]string, len(m))
+	for k, each := range m {
+		s, ok := toString(each)
+		if !ok {
+			return nil, false
+		}
+		sm[k] = s
+	}
+	return sm, true
+}
+
+// toURL returns the parsed URL of a string.
+func toURL(v interface{}) (*url.URL, bool) {
+	switch t := v.(type) {
+	case *url.URL:
+		return t, true
+	case string:
+		u, err := url.Parse(strings.TrimSpace(t))
+		return u, err == nil
+	}
+	return nil, false
+}
+
+// timeLayouts are tried in order to parse a time from a string.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// toTime returns the time of a timestamp or of a string in one of the timeLayouts.
+func toTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		for _, each := range timeLayouts {
+			if tt, err := time.Parse(each, strings.TrimSpace(t)); err == nil {
+				return tt, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -4,16 +4,24 @@ import "fmt"
 
 // ConnectEntry is a list element in the xconnect.connect config.
 type ConnectEntry struct {
-	Protocol string `yaml:"protocol,omitempty" json:"scheme,omitempty"`
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
 	Secure   *bool  `yaml:"secure,omitempty" json:"secure,omitempty"`
+	// TLS is the material used if secure is true
+	TLS      *TLS   `yaml:"tls-config,omitempty" json:"tls-config,omitempty"`
 	Host     string `yaml:"host,omitempty" json:"host,omitempty"`
 	Port     *int   `yaml:"port,omitempty" json:"port,omitempty"`
 	URL      string `yaml:"url,omitempty" json:"url,omitempty"`
 	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
 	Kind     string `yaml:"kind,omitempty" json:"kind,omitempty"`
 	// Resource is to identify the virtual listen part
-	Resource    string                 `yaml:"resource,omitempty" json:"resource,omitempty"`
-	ExtraFields map[string]interface{} `yaml:"-,inline"`
+	Resource string `yaml:"resource,omitempty" json:"resource,omitempty"`
+	// tuning of connections and requests ; see Tuning() for the defaults
+	ConnectTimeout *Duration              `yaml:"connect-timeout,omitempty" json:"connect-timeout,omitempty"`
+	RequestTimeout *Duration              `yaml:"request-timeout,omitempty" json:"request-timeout,omitempty"`
+	Retry          *RetryPolicy           `yaml:"retry,omitempty" json:"retry,omitempty"`
+	MaxConnections *int                   `yaml:"max-connections,omitempty" json:"max-connections,omitempty"`
+	IdleTimeout    *Duration              `yaml:"idle-timeout,omitempty" json:"idle-timeout,omitempty"`
+	ExtraFields    map[string]interface{} `yaml:"-,inline" json:"-"`
 }
 
 type ConnectionEnd interface {
@@ -35,11 +43,10 @@ func (e ConnectEntry) NetworkID() string {
 		return e.URL
 	}
 	if len(e.Host) != 0 {
-		p := 0
-		if e.Port != nil {
-			p = *e.Port
+		if p := e.EffectivePort(); p != 0 {
+			return fmt.Sprintf("%s:%d", e.Host, p)
 		}
-		return fmt.Sprintf("%s:%d", e.Host, p)
+		return e.Host
 	}
 	// url empty, host empty, we dont know
 	return ""
@@ -47,7 +54,7 @@ func (e ConnectEntry) NetworkID() string {
 
 func (e ConnectEntry) find(keys []string) (interface{}, bool) {
 	if len(keys) == 0 {
-		return nil, false
+		return e, true
 	}
 	switch keys[0] {
 	case "protocol":
@@ -57,6 +64,11 @@ func (e ConnectEntry) find(keys []string) (interface{}, bool) {
 			return *e.Secure, true
 		}
 		return nil, false
+	case "tls-config":
+		if e.TLS != nil {
+			return e.TLS.find(keys[1:])
+		}
+		return nil, false
 	case "host":
 		return e.Host, true
 	case "port":
@@ -72,7 +84,36 @@ func (e ConnectEntry) find(keys []string) (interface{}, bool) {
 		return e.Kind, true
 	case "resource":
 		return e.Resource, true
+	case "connect-timeout":
+		return findDuration(e.ConnectTimeout)
+	case "request-timeout":
+		return findDuration(e.RequestTimeout)
+	case "idle-timeout":
+		return findDuration(e.IdleTimeout)
+	case "retry":
+		if e.Retry != nil {
+			return e.Retry.find(keys[1:])
+		}
+		return nil, false
+	case "max-connections":
+		if e.MaxConnections != nil {
+			return *e.MaxConnections, true
+		}
+		return nil, false
 	default:
 		return findInMap(keys, e.ExtraFields)
 	}
 }
+
+// EffectivePort returns the port, or the port of the URL, or the default port of the protocol ; 0 if unknown.
+func (e ConnectEntry) EffectivePort() int {
+	if e.Port != nil {
+		return *e.Port
+	}
+	return e.Endpoint().Port
+}
+
+// EffectiveSecure returns the secure value, or whether the URL scheme implies it, or the default of the protocol.
+func (e ConnectEntry) EffectiveSecure() bool {
+	return effectiveSecure(e.Protocol, e.Secure, urlScheme(e.URL))
+}
@@ -0,0 +1,436 @@
+package xconnect
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	yaml2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// An encrypted value is written as ENC[AES256_GCM,<base64 of nonce and ciphertext>].
+// The plaintext is the YAML of the scalar, such that its type is kept, e.g. 5432 or '0123' .
+const (
+	encryptedPrefix = "ENC[AES256_GCM,"
+	encryptedSuffix = "]"
+)
+
+// KeyFileEnv is the environment variable with the name of the key file, used if a Loader has no Key.
+const KeyFileEnv = "XCONNECT_KEY_FILE"
+
+// ReadKeyFile returns the 32 byte AES-256 key from a file with the key as base64, hex or raw bytes.
+// A key can be created with: head -c 32 /dev/urandom | base64 > xconnect.key
+func ReadKeyFile(filename string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key file:%v", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if k, err := base64.StdEncoding.DecodeString(text); err == nil && len(k) == 32 {
+		return k, nil
+	}
+	if k, err := hex.DecodeString(text); err == nil && len(k) == 32 {
+		return k, nil
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	return nil, fmt.Errorf("key in [%s] must be 32 bytes, base64 or hex encoded", filename)
+}
+
+// IsEncrypted returns true if the value is an ENC[...] envelope.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encryptedPrefix) && strings.HasSuffix(s, encryptedSuffix)
+}
+
+// Encrypt returns the ENC[...] envelope of a scalar value, using AES-256-GCM with the key.
+func Encrypt(key []byte, v interface{}) (string, error) {
+	data, err := yaml2.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal value:%v", err)
+	}
+	return sealValue(key, strings.TrimSuffix(string(data), "\n"))
+}
+
+// Decrypt returns the scalar value of an ENC[...] envelope.
+func Decrypt(key []byte, s string) (interface{}, error) {
+	plain, err := openValue(key, s)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml2.Unmarshal([]byte(plain), &v); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal decrypted value:%v", err)
+	}
+	return v, nil
+}
+
+// sealValue encrypts the YAML text of a scalar.
+func sealValue(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+// openValue decrypts an envelope into the YAML text of a scalar.
+func openValue(key []byte, s string) (string, error) {
+	if !IsEncrypted(s) {
+		return "", errors.New("value is not encrypted")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(s[len(encryptedPrefix) : len(s)-len(encryptedSuffix)])
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value:%v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("unable to decrypt value, wrong key?")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loaderKey returns the key of the loader, or else the key from the file named by XCONNECT_KEY_FILE, if set.
+func (l *Loader) loaderKey() ([]byte, error) {
+	if l.Key != nil {
+		return l.Key, nil
+	}
+	if name := os.Getenv(KeyFileEnv); name != "" {
+		return ReadKeyFile(name)
+	}
+	return nil, nil
+}
+
+// decryptTree replaces each encrypted value in the tree by its decrypted value.
+func decryptTree(v interface{}, path string, key func() ([]byte, error)) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		if !IsEncrypted(t) {
+			return t, nil
+		}
+		k, err := key()
+		if err != nil {
+			return nil, err
+		}
+		if k == nil {
+			return nil, fmt.Errorf("encrypted value at [%s] requires a key, set %s", path, KeyFileEnv)
+		}
+		d, err := Decrypt(k, t)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt value at [%s]:%v", path, err)
+		}
+		return d, nil
+	case map[interface{}]interface{}:
+		for k, each := range t {
+			d, err := decryptTree(each, joinPath(path, escapeKey(toKey(k))), key)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = d
+		}
+	case []interface{}:
+		for i, each := range t {
+			d, err := decryptTree(each, joinPath(path, fmt.Sprint(i)), key)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = d
+		}
+	}
+	return v, nil
+}
+
+// dropEncryptedFields removes the encrypted values of fields that are not strings, such as port or secure,
+// from a tree that is decoded into a value of type rt ; without the key these values cannot be decoded.
+func dropEncryptedFields(v interface{}, rt reflect.Type) {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return
+	}
+	switch rt.Kind() {
+	case reflect.Map:
+		for _, each := range m {
+			dropEncryptedFields(each, rt.Elem())
+		}
+	case reflect.Struct:
+		fields := map[string]reflect.Type{}
+		for i := 0; i < rt.NumField(); i++ {
+			if name, _ := yamlFieldName(rt.Field(i)); name != "" {
+				fields[name] = rt.Field(i).Type
+			}
+		}
+		for k, each := range m {
+			ft, ok := fields[toKey(k)]
+			if !ok {
+				continue
+			}
+			if s, ok := each.(string); ok && IsEncrypted(s) {
+				for ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() != reflect.String {
+					delete(m, k)
+				}
+				continue
+			}
+			dropEncryptedFields(each, ft)
+		}
+	}
+}
+
+// EncryptYAML returns the content with encrypted values for all fields with sensitive names, such as password or token,
+// for URLs with credentials and for fields with one of the extra names.
+// Other text, such as structure, comments and non-secret fields, is kept as is. It also returns the number of encrypted values.
+func EncryptYAML(content []byte, key []byte, names ...string) ([]byte, int, error) {
+	extra := map[string]bool{}
+	for _, each := range names {
+		extra[each] = true
+	}
+	return editScalars(content, func(name, raw string) (string, bool, error) {
+		value := strings.Trim(raw, `"'`)
+		if value == "" || IsEncrypted(value) {
+			return "", false, nil
+		}
+		if _, _, ok := parseSecretRef(value); ok {
+			return "", false, nil
+		}
+		if !extra[name] && !isSensitiveName(name) && Redact(value) == value {
+			return "", false, nil
+		}
+		s, err := sealValue(key, raw)
+		return s, true, err
+	})
+}
+
+// DecryptYAML returns the content with all encrypted values replaced by their original text.
+// It also returns the number of decrypted values.
+func DecryptYAML(content []byte, key []byte) ([]byte, int, error) {
+	return editScalars(content, func(name, raw string) (string, bool, error) {
+		if !IsEncrypted(raw) {
+			return "", false, nil
+		}
+		s, err := openValue(key, raw)
+		return s, true, err
+	})
+}
+
+// scalarEdit is a replacement of the source text of a scalar.
+type scalarEdit struct {
+	start, end int
+	text       string
+}
+
+// editScalars calls edit for each scalar value of a mapping in block style, with its key and source text,
+// and replaces the source text if edit returns true.
+// Values in a literal block scalar that holds a YAML mapping, such as the data of a Kubernetes ConfigMap, are edited too.
+func editScalars(content []byte, edit func(name, raw string) (string, bool, error)) ([]byte, int, error) {
+	edits, err := scalarEdits(content, edit)
+	if err != nil {
+		return nil, 0, err
+	}
+	// replace from the end such that offsets stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte{}, content...)
+	for _, each := range edits {
+		out = append(out[:each.start], append([]byte(each.text), out[each.end:]...)...)
+	}
+	return out, len(edits), nil
+}
+
+// scalarEdits returns the replacements by edit for the scalar values in the content, see editScalars.
+func scalarEdits(content []byte, edit func(name, raw string) (string, bool, error)) ([]scalarEdit, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal YAML:%v", err)
+	}
+	lines := lineOffsets(content)
+	edits := []scalarEdit{}
+	var walk func(n *yaml.Node) error
+	walk = func(n *yaml.Node) error {
+		if n.Style&yaml.FlowStyle != 0 {
+			// keep it simple ; values in flow style are not edited
+			return nil
+		}
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				if v.Kind != yaml.ScalarNode {
+					if err := walk(v); err != nil {
+						return err
+					}
+					continue
+				}
+				if v.Tag == "!!null" || v.Style&yaml.FoldedStyle != 0 {
+					continue
+				}
+				if v.Style&yaml.LiteralStyle != 0 {
+					inner, err := blockEdits(content, lines, v, edit)
+					if err != nil {
+						return err
+					}
+					edits = append(edits, inner...)
+					continue
+				}
+				start := lines[v.Line-1] + byteColumn(content[lines[v.Line-1]:], v.Column-1)
+				end := scalarEnd(content, start)
+				raw := string(content[start:end])
+				if v.Style == 0 && raw != v.Value {
+					// a plain scalar on multiple lines
+					continue
+				}
+				text, ok, err := edit(k.Value, raw)
+				if err != nil {
+					return fmt.Errorf("line %d:%v", v.Line, err)
+				}
+				if ok {
+					edits = append(edits, scalarEdit{start: start, end: end, text: text})
+				}
+			}
+			return nil
+		}
+		for _, each := range n.Content {
+			if err := walk(each); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(&root); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// blockEdits returns the edits for the YAML mapping in a literal block scalar, with offsets in the content of the block scalar.
+// A block scalar with other text, e.g. properties, has no edits.
+func blockEdits(content []byte, lines []int, v *yaml.Node, edit func(name, raw string) (string, bool, error)) ([]scalarEdit, error) {
+	var inner yaml.Node
+	if err := yaml.Unmarshal([]byte(v.Value), &inner); err != nil || len(inner.Content) == 0 || inner.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	edits, err := scalarEdits([]byte(v.Value), edit)
+	if err != nil {
+		return nil, fmt.Errorf("block at line %d:%v", v.Line, err)
+	}
+	// the text of the block starts on the line after the indicator
+	first, indent := v.Line, blockIndent(content, lines, v.Line)
+	innerLines := lineOffsets([]byte(v.Value))
+	outer := func(offset int) int {
+		i := sort.SearchInts(innerLines, offset+1) - 1
+		return lines[first+i] + indent + offset - innerLines[i]
+	}
+	for i, each := range edits {
+		edits[i].start, edits[i].end = outer(each.start), outer(each.end)
+	}
+	return edits, nil
+}
+
+// blockIndent returns the indentation of the first non-blank line of a block scalar, starting at a line index ;
+// each line of a literal block scalar has this indentation.
+func blockIndent(content []byte, lines []int, first int) int {
+	for i := first; i < len(lines); i++ {
+		line := content[lines[i]:]
+		if nl := bytes.IndexByte(line, '\n'); nl != -1 {
+			line = line[:nl]
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			return len(line) - len(bytes.TrimLeft(line, " "))
+		}
+	}
+	return 0
+}
+
+// lineOffsets returns the byte offset of the start of each line.
+func lineOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, each := range content {
+		if each == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// byteColumn returns the byte offset of a (rune) column in a line.
+func byteColumn(line []byte, column int) int {
+	offset := 0
+	for i := 0; i < column && offset < len(line); i++ {
+		_, size := utf8.DecodeRune(line[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// scalarEnd returns the end offset of the quoted or plain scalar that starts at start.
+func scalarEnd(content []byte, start int) int {
+	switch content[start] {
+	case '\'':
+		for i := start + 1; i < len(content); i++ {
+			if content[i] == '\'' {
+				if i+1 < len(content) && content[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+	case '"':
+		for i := start + 1; i < len(content); i++ {
+			if content[i] == '\\' {
+				i++
+				continue
+			}
+			if content[i] == '"' {
+				return i + 1
+			}
+		}
+	}
+	end := bytes.IndexByte(content[start:], '\n')
+	if end == -1 {
+		end = len(content) - start
+	}
+	line := content[start : start+end]
+	if c := bytes.Index(line, []byte(" #")); c != -1 {
+		line = line[:c]
+	}
+	return start + len(bytes.TrimRight(line, " \t\r"))
+}
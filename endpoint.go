@@ -0,0 +1,141 @@
+package xconnect
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is the normalized network address of a listen or connect entry.
+// Use Matches to find out whether a connect entry refers to a listen entry.
+type Endpoint struct {
+	// Scheme is the lowercase protocol or URL scheme, if known.
+	Scheme string
+	// Host is the lowercase host name or IP address ; an IPv6 address has no brackets.
+	Host string
+	// Port is the explicit port or the default port of the scheme, if known.
+	Port int
+	// Path is the path of a URL without a trailing slash.
+	Path string
+}
+
+// kubernetesDomain is the suffix of fully qualified service names in a Kubernetes cluster.
+const kubernetesDomain = ".svc.cluster.local"
+
+// Endpoint returns the normalized address of this entry.
+func (e ListenEntry) Endpoint() Endpoint {
+	return newEndpoint(e.Protocol, e.Secure, e.Host, e.Port, e.URL)
+}
+
+// Endpoint returns the normalized address of this entry.
+func (e ConnectEntry) Endpoint() Endpoint {
+	return newEndpoint(e.Protocol, e.Secure, e.Host, e.Port, e.URL)
+}
+
+func newEndpoint(protocol string, secure *bool, host string, port *int, rawURL string) Endpoint {
+	p := Endpoint{Scheme: strings.ToLower(protocol)}
+	if len(rawURL) != 0 && !strings.Contains(rawURL, "://") {
+		// no scheme, e.g. svc:80 ; the protocol of the entry gives the default port
+		hostPort := rawURL
+		if slash := strings.Index(rawURL, "/"); slash != -1 {
+			hostPort, p.Path = rawURL[:slash], strings.TrimSuffix(rawURL[slash:], "/")
+		}
+		hp, err := parseHostPort(hostPort, 0)
+		if err != nil {
+			return p
+		}
+		host, port = hp.Host, &hp.Port
+		if hp.Port == 0 {
+			port = nil
+		}
+	} else if len(rawURL) != 0 {
+		c, err := ParseConnectionString(rawURL, secure)
+		if err != nil {
+			return p
+		}
+		// the first host represents the connection
+		return c.Endpoints()[0]
+	}
+	p.Host = normalizeHost(host)
+	if port != nil {
+		p.Port = *port
+	}
+	if p.Port == 0 {
+		p.Port = defaultPort(p.Scheme, effectiveSecure(protocol, secure, p.Scheme))
+	}
+	return p
+}
+
+// normalizeHost returns the lowercase host without a trailing dot or IPv6 brackets.
+// A Kubernetes service name such as svc.ns.svc is expanded to svc.ns.svc.cluster.local .
+func normalizeHost(host string) string {
+	h := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	h = strings.TrimSuffix(strings.TrimPrefix(h, "["), "]")
+	if ip := net.ParseIP(h); ip != nil {
+		return ip.String()
+	}
+	if strings.HasSuffix(h, ".svc") {
+		return h + ".cluster.local"
+	}
+	return h
+}
+
+// IsZero returns true if the host is unknown.
+func (p Endpoint) IsZero() bool {
+	return p.Host == ""
+}
+
+// Address returns HOST:PORT, or just HOST if the port is unknown.
+func (p Endpoint) Address() string {
+	if p.Port == 0 {
+		if strings.Contains(p.Host, ":") {
+			return "[" + p.Host + "]"
+		}
+		return p.Host
+	}
+	return net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+}
+
+// String returns SCHEME://HOST:PORT/PATH with the parts that are known.
+func (p Endpoint) String() string {
+	s := p.Address() + p.Path
+	if p.Scheme != "" {
+		s = p.Scheme + "://" + s
+	}
+	return s
+}
+
+// ExpandHost returns a copy with a single label host, such as a Kubernetes service name,
+// expanded to its fully qualified name in the given namespace.
+func (p Endpoint) ExpandHost(namespace string) Endpoint {
+	if p.Host != "" && namespace != "" && !strings.Contains(p.Host, ".") && !strings.Contains(p.Host, ":") {
+		p.Host = p.Host + "." + namespace + kubernetesDomain
+	}
+	return p
+}
+
+// Matches returns true if both endpoints refer to the same host and port.
+// Schemes and paths are ignored. A Kubernetes service name with its namespace, e.g. svc.ns,
+// matches its fully qualified name svc.ns.svc.cluster.local . A single label name such as svc
+// only matches after ExpandHost with the namespace it is resolved in.
+func (p Endpoint) Matches(o Endpoint) bool {
+	if p.IsZero() || o.IsZero() || p.Port != o.Port {
+		return false
+	}
+	return p.Host == o.Host || isShortNameOf(p.Host, o.Host) || isShortNameOf(o.Host, p.Host)
+}
+
+// isShortNameOf returns true if short is svc.ns and full is svc.ns.svc.cluster.local .
+func isShortNameOf(short, full string) bool {
+	return strings.HasSuffix(full, kubernetesDomain) && short == strings.TrimSuffix(full, kubernetesDomain)
+}
+
+// urlScheme returns the lowercase scheme of a URL, if any.
+func urlScheme(rawURL string) string {
+	u, err := url.Parse(strings.TrimPrefix(rawURL, "jdbc:"))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
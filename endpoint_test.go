@@ -0,0 +1,67 @@
+package xconnect
+
+import "testing"
+
+func TestEndpointNormalization(t *testing.T) {
+	port := func(i int) *int { return &i }
+	yes := true
+	for i, each := range []struct {
+		entry ConnectEntry
+		want  string
+	}{
+		{ConnectEntry{URL: "http://SVC:80/api/"}, "http://svc:80/api"},
+		{ConnectEntry{URL: "http://svc"}, "http://svc:80"},
+		{ConnectEntry{URL: "https://svc"}, "https://svc:443"},
+		{ConnectEntry{Protocol: "HTTP", Host: "svc"}, "http://svc:80"},
+		{ConnectEntry{Protocol: "grpc", Host: "svc", Secure: &yes}, "grpc://svc:443"},
+		{ConnectEntry{Host: "svc.ns.svc", Port: port(8080)}, "svc.ns.svc.cluster.local:8080"},
+		{ConnectEntry{Host: "2001:DB8:0:0::1", Port: port(80)}, "[2001:db8::1]:80"},
+		{ConnectEntry{URL: "http://[2001:db8::1]:8080/"}, "http://[2001:db8::1]:8080"},
+		{ConnectEntry{URL: "jdbc:postgresql://localhost:5432/postgres"}, "postgresql://localhost:5432/postgres"},
+		{ConnectEntry{Host: "tcp.net"}, "tcp.net"},
+		{ConnectEntry{URL: "svc:80"}, "svc:80"},
+		{ConnectEntry{Protocol: "http", URL: "svc/api/"}, "http://svc:80/api"},
+	} {
+		if got, want := each.entry.Endpoint().String(), each.want; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+}
+
+func TestEndpointMatches(t *testing.T) {
+	port := 80
+	listen := ListenEntry{Protocol: "http", Host: "svc.ns.svc.cluster.local", Port: &port}
+	for i, each := range []struct {
+		entry     ConnectEntry
+		namespace string
+		want      bool
+	}{
+		{ConnectEntry{URL: "http://svc:80"}, "ns", true},
+		{ConnectEntry{URL: "http://svc"}, "ns", true},
+		{ConnectEntry{URL: "svc:80"}, "ns", true},
+		{ConnectEntry{URL: "http://svc.ns/some/path"}, "", true},
+		{ConnectEntry{Host: "svc", Port: &port}, "ns", true},
+		{ConnectEntry{Host: "svc.ns.svc", Port: &port}, "", true},
+		{ConnectEntry{Host: "svc", Port: &port}, "", false},
+		{ConnectEntry{Host: "svc", Port: &port}, "other", false},
+		{ConnectEntry{Host: "svc.other", Port: &port}, "", false},
+		{ConnectEntry{Host: "other", Port: &port}, "ns", false},
+		{ConnectEntry{URL: "https://svc"}, "ns", false},
+		{ConnectEntry{Kind: "db"}, "ns", false},
+	} {
+		if got, want := each.entry.Endpoint().ExpandHost(each.namespace).Matches(listen.Endpoint()), each.want; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+}
+
+func TestEndpointExpandHost(t *testing.T) {
+	e := Endpoint{Host: "svc", Port: 80}.ExpandHost("ns")
+	if got, want := e.Host, "svc.ns.svc.cluster.local"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	e = Endpoint{Host: "there.com", Port: 80}.ExpandHost("ns")
+	if got, want := e.Host, "there.com"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package xconnect
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is the error, see NotFoundError, when a slash path has no value.
+// Use errors.Is(err, xconnect.ErrNotFound) to check.
+var ErrNotFound = errors.New("xconnect: not found")
+
+// NotFoundError is returned when a slash path has no value.
+type NotFoundError struct {
+	// Path is the path that was looked up, e.g. xconnect/connect/some-db/url .
+	Path string
+	// Resolved is the longest prefix of Path that has a value, e.g. xconnect/connect if the connect id is misspelled.
+	Resolved string
+	// Missing is the first key after Resolved that has no value, e.g. some-db .
+	Missing string
+	// Expected is the name of the type that was looked up, e.g. string.
+	Expected string
+	// Position is the source position of Resolved, if known.
+	Position Position
+}
+
+func (e *NotFoundError) Error() string {
+	return withPosition(e.Position, fmt.Sprintf("unable to find %s at [%s]: no [%s] in [%s]", e.Expected, e.Path, e.Missing, e.Resolved))
+}
+
+// Is returns true for ErrNotFound.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// TypeMismatchError is returned when the value at a slash path cannot be converted to the expected type.
+type TypeMismatchError struct {
+	// Path is the path of the value, e.g. xconnect/listen/api/port .
+	Path string
+	// Expected is the name of the type that was looked up, e.g. int.
+	Expected string
+	// Actual is the Go type of the value, e.g. []interface {}.
+	Actual string
+	// Position is the source position of the value, if known.
+	Position Position
+}
+
+func (e *TypeMismatchError) Error() string {
+	return withPosition(e.Position, fmt.Sprintf("value at [%s] is not a %s but a %s", e.Path, e.Expected, e.Actual))
+}
+
+// withPosition returns the message prefixed by the position, if known.
+func withPosition(p Position, message string) string {
+	if p.IsZero() {
+		return message
+	}
+	return p.String() + ": " + message
+}
+
+// notFound returns the error for the keys of a path without value, with the longest prefix that has a value.
+func notFound(f finder, path string, keys []string, typeName string) error {
+	resolved := 0
+	for i := len(keys) - 1; i > 0; i-- {
+		if _, ok := f.find(keys[:i]); ok {
+			resolved = i
+			break
+		}
+	}
+	err := &NotFoundError{
+		Path:     path,
+		Resolved: joinKeys(keys[:resolved]),
+		Missing:  keys[resolved],
+		Expected: typeName,
+	}
+	if p, ok := f.(positioner); ok && resolved > 0 {
+		err.Position, _ = p.position(keys[:resolved])
+	}
+	return err
+}
+
+func mismatch(f finder, path string, typeName string, v interface{}) error {
+	err := &TypeMismatchError{Path: path, Expected: typeName, Actual: fmt.Sprintf("%T", v)}
+	if p, ok := f.(positioner); ok {
+		err.Position, _ = p.position(splitPath(path))
+	}
+	return err
+}
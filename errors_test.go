@@ -0,0 +1,66 @@
+package xconnect
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNotFoundError(t *testing.T) {
+	doc, err := LoadConfig("testdata/accessors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []struct {
+		path, resolved, missing string
+	}{
+		{"xconnect/connect/evnts/kind", "xconnect/connect", "evnts"},
+		{"xconnect/connect/events/params/user", "xconnect/connect/events/params", "user"},
+		{"xconnect/meta/tags/5", "xconnect/meta/tags", "5"},
+		{"other", "", "other"},
+	} {
+		_, err := doc.FindString(each.path)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got [%v] want ErrNotFound", err)
+		}
+		var nerr *NotFoundError
+		if !errors.As(err, &nerr) {
+			t.Fatalf("got [%T] want *NotFoundError", err)
+		}
+		if got, want := nerr.Resolved, each.resolved; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+		if got, want := nerr.Missing, each.missing; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+	}
+	if got, want := doc.IntOr("xconnect/connect/evnts/partitions", 1), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestTypeMismatchError(t *testing.T) {
+	doc, err := LoadConfig("testdata/accessors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doc.FindInt("xconnect/connect/events/topics")
+	var merr *TypeMismatchError
+	if !errors.As(err, &merr) {
+		t.Fatalf("got [%T] want *TypeMismatchError", err)
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Error("mismatch is not ErrNotFound")
+	}
+	if got, want := merr.Path, "xconnect/connect/events/topics"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := merr.Expected, "int"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := merr.Actual, "[]interface {}"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := err.Error(), "testdata/accessors.yaml:16:7: value at [xconnect/connect/events/topics] is not a int but a []interface {}"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/emicklei/dot v0.16.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
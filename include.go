@@ -0,0 +1,114 @@
+package xconnect
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A map in a document can be replaced by (part of) another YAML file:
+//
+//	connect: { $include: ../shared/datastores.yaml }
+//	main-db: { $ref: shared.yaml#/connect/main-postgres }
+//
+// File names are relative to the including file. The part after the # is a slash path from the root of that file.
+// Other keys next to $include or $ref are merged on top of the included value, using the overlay rules.
+const (
+	includeKey = "$include"
+	refKey     = "$ref"
+)
+
+// includeAll replaces all $include and $ref maps in a document tree.
+// The positions of included keys and list elements are added to the index.
+func includeAll(tree map[interface{}]interface{}, dir string, stack []string, idx positionIndex) (map[interface{}]interface{}, error) {
+	resolved, err := resolveIncludes(tree, dir, stack, "", idx)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := resolved.(map[interface{}]interface{}); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("included document is not a map but [%T]", resolved)
+}
+
+// resolveIncludes replaces all $include and $ref maps in the tree.
+// The stack holds the files (with fragment) being included, to detect cycles. The path is the slash path of v.
+func resolveIncludes(v interface{}, dir string, stack []string, path string, idx positionIndex) (interface{}, error) {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		for _, key := range []string{includeKey, refKey} {
+			if target, ok := t[key]; ok {
+				return resolveInclude(t, key, target, dir, stack, path, idx)
+			}
+		}
+		for k, each := range t {
+			resolved, err := resolveIncludes(each, dir, stack, joinPath(path, escapeKey(toKey(k))), idx)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = resolved
+		}
+	case []interface{}:
+		for i, each := range t {
+			resolved, err := resolveIncludes(each, dir, stack, joinPath(path, strconv.Itoa(i)), idx)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = resolved
+		}
+	}
+	return v, nil
+}
+
+func resolveInclude(m map[interface{}]interface{}, key string, target interface{}, dir string, stack []string, path string, idx positionIndex) (interface{}, error) {
+	location, ok := target.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of [%s] is not a string but [%T]", key, target)
+	}
+	filename, fragment := location, ""
+	if i := strings.Index(location, "#"); i != -1 {
+		filename, fragment = location[:i], location[i+1:]
+	}
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	id := filename + "#" + fragment
+	for _, each := range stack {
+		if each == id {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, id), " -> "))
+		}
+	}
+	tree, positions, err := parseFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to %s [%s]:%v", key, location, err)
+	}
+	var included interface{} = tree
+	from := ""
+	if keys := splitPath(strings.Trim(fragment, extraPathSeparator)); len(fragment) > 0 {
+		found, ok := findInValue(keys, tree)
+		if !ok {
+			return nil, fmt.Errorf("unable to %s [%s]: no value at [%s]", key, location, fragment)
+		}
+		included = found
+		from = joinKeys(keys)
+	}
+	idx.include(positions, from, path)
+	included, err = resolveIncludes(included, filepath.Dir(filename), append(stack[:len(stack):len(stack)], id), path, idx)
+	if err != nil {
+		return nil, err
+	}
+	// merge the other keys on top
+	delete(m, key)
+	if len(m) == 0 {
+		return included, nil
+	}
+	if _, ok := included.(map[interface{}]interface{}); !ok {
+		return nil, fmt.Errorf("unable to merge fields into [%s], value is not a map but [%T]", location, included)
+	}
+	overlay, err := resolveIncludes(m, dir, stack, path, idx)
+	if err != nil {
+		return nil, err
+	}
+	return merge(included, overlay), nil
+}
@@ -0,0 +1,111 @@
+package xconnect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigIncludes(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"shared/datastores.yaml": `
+main-postgres:
+  url: jdbc:postgresql://main-postgres:5432/main
+main-redis:
+  $ref: redis.yaml#/connect/redis
+`,
+		"shared/redis.yaml": `
+connect:
+  redis:
+    host: main-redis
+    port: 6379
+`,
+		"service/xconnect.yaml": `
+xconnect:
+  meta:
+    name: account-service
+  connect:
+    $include: ../shared/datastores.yaml
+    events:
+      kind: gcp.pubsub
+`,
+		"other/xconnect.yaml": `
+xconnect:
+  meta:
+    name: other-service
+  connect:
+    db:
+      $ref: ../shared/datastores.yaml#/main-postgres
+      kind: postgres
+`,
+	})
+	defer os.RemoveAll(dir)
+	doc, err := LoadConfig(filepath.Join(dir, "service/xconnect.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := doc.XConnect
+	if got, want := len(x.Connect), 3; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := x.Connect["main-postgres"].URL, "jdbc:postgresql://main-postgres:5432/main"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := x.Connect["main-redis"].Host, "main-redis"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	doc, err = LoadConfig(filepath.Join(dir, "other/xconnect.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := doc.XConnect.Connect["db"]
+	if got, want := db.URL, "jdbc:postgresql://main-postgres:5432/main"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := db.Kind, "postgres"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestLoadConfigIncludeCycle(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"a.yaml": `
+xconnect:
+  connect:
+    $include: b.yaml
+`,
+		"b.yaml": `
+b:
+  $ref: a.yaml#/xconnect/connect
+`,
+	})
+	defer os.RemoveAll(dir)
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadConfigIncludeMissingFragment(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"a.yaml": `
+xconnect:
+  connect:
+    db:
+      $ref: b.yaml#/connect/missing
+`,
+		"b.yaml": `
+connect:
+  db:
+    host: db
+`,
+	})
+	defer os.RemoveAll(dir)
+	if _, err := LoadConfig(filepath.Join(dir, "a.yaml")); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,168 @@
+package xconnect
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// placeholderPattern matches ${name} and ${name:default}.
+var placeholderPattern = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
+
+// referenceSeparator separates the keys of an in-document reference, e.g. ${xconnect.connect.some-db.url} .
+const referenceSeparator = "."
+
+// InterpolationError lists all references that could not be resolved.
+type InterpolationError struct {
+	// Unresolved has an entry for each reference that has no value, nor a default value.
+	Unresolved []string
+	// Cycles has an entry for each reference that (indirectly) refers to itself.
+	Cycles []string
+}
+
+func (e *InterpolationError) Error() string {
+	var b strings.Builder
+	b.WriteString("unable to resolve references:")
+	for _, each := range e.Unresolved {
+		fmt.Fprintf(&b, " [%s]", each)
+	}
+	for _, each := range e.Cycles {
+		fmt.Fprintf(&b, " [cycle %s]", each)
+	}
+	return b.String()
+}
+
+// interpolator resolves references using the document itself and the environment.
+type interpolator struct {
+	root      interface{}
+	lookupEnv func(string) (string, bool)
+	// resolving holds the references being resolved, to detect cycles
+	resolving []string
+	err       *InterpolationError
+}
+
+// interpolate replaces all references in string values of the tree.
+// A reference is first looked up in the document (using dots as separator), then in the environment.
+// If both are missing then the default value is used, if present.
+// If lookupEnv is nil then os.LookupEnv is used.
+func interpolate(tree map[interface{}]interface{}, lookupEnv func(string) (string, bool)) error {
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	in := &interpolator{root: tree, lookupEnv: lookupEnv, err: new(InterpolationError)}
+	in.walk(tree, nil)
+	if len(in.err.Unresolved) > 0 || len(in.err.Cycles) > 0 {
+		return in.err
+	}
+	return nil
+}
+
+func (in *interpolator) walk(v interface{}, path []string) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		for k, each := range t {
+			t[k] = in.walk(each, append(path[:len(path):len(path)], toKey(k)))
+		}
+	case []interface{}:
+		for i, each := range t {
+			t[i] = in.walk(each, append(path[:len(path):len(path)], strconv.Itoa(i)))
+		}
+	case string:
+		return in.resolveString(t, joinKeys(path))
+	}
+	return v
+}
+
+// resolveString replaces all references in s.
+// If s is a single reference then the value keeps its type, e.g. a port number.
+func (in *interpolator) resolveString(s string, path string) interface{} {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	if loc := placeholderPattern.FindStringSubmatchIndex(s); loc != nil && loc[0] == 0 && loc[1] == len(s) {
+		v, _ := in.resolve(s, path)
+		return v
+	}
+	return placeholderPattern.ReplaceAllStringFunc(s, func(each string) string {
+		v, ok := in.resolve(each, path)
+		if !ok {
+			return each
+		}
+		if s, ok := toString(v); ok {
+			return s
+		}
+		in.err.Unresolved = appendOnce(in.err.Unresolved, fmt.Sprintf("%s at %s is not a scalar", each, path))
+		return each
+	})
+}
+
+// resolve returns the value of a single placeholder.
+func (in *interpolator) resolve(placeholder string, path string) (interface{}, bool) {
+	match := placeholderPattern.FindStringSubmatch(placeholder)
+	name, defaultValue, hasDefault := match[1], match[2], strings.Contains(placeholder, ":")
+	for _, each := range in.resolving {
+		if each == name {
+			in.err.Cycles = appendOnce(in.err.Cycles, strings.Join(append(in.resolving, name), " -> "))
+			return placeholder, false
+		}
+	}
+	if v, ok := findReference(in.root, strings.Split(name, referenceSeparator)); ok {
+		if s, ok := v.(string); ok {
+			in.resolving = append(in.resolving, name)
+			defer func() { in.resolving = in.resolving[:len(in.resolving)-1] }()
+			return in.resolveString(s, path), true
+		}
+		return v, true
+	}
+	if s, ok := in.lookupEnv(name); ok {
+		return scalar(s), true
+	}
+	if hasDefault {
+		return scalar(defaultValue), true
+	}
+	in.err.Unresolved = appendOnce(in.err.Unresolved, fmt.Sprintf("%s at %s", placeholder, path))
+	return placeholder, false
+}
+
+// findReference returns the value for the keys of a reference.
+// Keys that contain the separator themselves, such as gcp.pubsub, are matched too.
+func findReference(v interface{}, keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return v, true
+	}
+	for n := len(keys); n > 0; n-- {
+		key := strings.Join(keys[:n], referenceSeparator)
+		if child, ok := findInValue([]string{key}, v); ok {
+			if found, ok := findReference(child, keys[n:]); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// scalar returns the YAML interpretation of a string, e.g. "8080" becomes an int.
+func scalar(s string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	switch v.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return v
+	}
+	return s
+}
+
+func appendOnce(list []string, s string) []string {
+	for _, each := range list {
+		if each == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,86 @@
+package xconnect
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("XCONNECT_TEST_HOST", "db.local")
+	os.Setenv("XCONNECT_TEST_PORT", "5433")
+	defer os.Unsetenv("XCONNECT_TEST_HOST")
+	defer os.Unsetenv("XCONNECT_TEST_PORT")
+	os.Setenv("XCONNECT_TEST_CONFIG", `
+xconnect:
+  meta:
+    name: ${XCONNECT_TEST_NAME:account-service}
+  listen:
+    api:
+      port: ${XCONNECT_TEST_PORT}
+  connect:
+    some-db:
+      url: jdbc:postgresql://${XCONNECT_TEST_HOST}:${XCONNECT_TEST_PORT}/postgres
+    gcp.pubsub:
+      resource: ${xconnect.meta.name}-topic
+spring:
+  datasource:
+    url: ${xconnect.connect.some-db.url}
+    port: ${xconnect.listen.api.port}
+    topic: ${xconnect.connect.gcp.pubsub.resource}
+`)
+	defer os.Unsetenv("XCONNECT_TEST_CONFIG")
+	doc, err := GetConfig("XCONNECT_TEST_CONFIG", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.XConnect.Meta.Name, "account-service"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := *doc.XConnect.Listen["api"].Port, 5433; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustString("spring/datasource/url"), "jdbc:postgresql://db.local:5433/postgres"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustInt("spring/datasource/port"), 5433; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustString("spring/datasource/topic"), "account-service-topic"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestInterpolateUnresolved(t *testing.T) {
+	os.Setenv("XCONNECT_TEST_CONFIG", `
+xconnect:
+  meta:
+    name: ${XCONNECT_TEST_MISSING}
+  connect:
+    a:
+      url: ${xconnect.connect.b.url}
+    b:
+      url: ${xconnect.connect.a.url}
+    c:
+      host: ${xconnect.connect.missing.host}
+`)
+	defer os.Unsetenv("XCONNECT_TEST_CONFIG")
+	_, err := GetConfig("XCONNECT_TEST_CONFIG", "")
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	var ierr *InterpolationError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("got [%T] want InterpolationError", err)
+	}
+	if got, want := len(ierr.Unresolved), 2; got != want {
+		t.Errorf("got [%v] want [%v]:%v", got, want, ierr.Unresolved)
+	}
+	if got, want := len(ierr.Cycles), 2; got != want {
+		t.Errorf("got [%v] want [%v]:%v", got, want, ierr.Cycles)
+	}
+	if !strings.Contains(err.Error(), "${XCONNECT_TEST_MISSING} at xconnect/meta/name") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
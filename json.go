@@ -0,0 +1,187 @@
+package xconnect
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// The JSON representation has the same structure as the YAML representation ;
+// extra fields are flattened into the object that holds them.
+
+// MarshalJSON is part of json.Marshaler
+func (d Document) MarshalJSON() ([]byte, error) {
+	type plain Document
+	return marshalWithExtras(plain(d), d.ExtraFields)
+}
+
+// UnmarshalJSON is part of json.Unmarshaler
+func (d *Document) UnmarshalJSON(data []byte) error {
+	type plain Document
+	var p plain
+	extras, err := unmarshalWithExtras(data, &p)
+	if err != nil {
+		return err
+	}
+	*d = Document(p)
+	d.ExtraFields = extras
+	return nil
+}
+
+// MarshalJSON is part of json.Marshaler
+func (x XConnect) MarshalJSON() ([]byte, error) {
+	type plain XConnect
+	return marshalWithExtras(plain(x), x.ExtraFields)
+}
+
+// UnmarshalJSON is part of json.Unmarshaler
+func (x *XConnect) UnmarshalJSON(data []byte) error {
+	type plain XConnect
+	var p plain
+	extras, err := unmarshalWithExtras(data, &p)
+	if err != nil {
+		return err
+	}
+	*x = XConnect(p)
+	x.ExtraFields = extras
+	return nil
+}
+
+// MarshalJSON is part of json.Marshaler
+func (m MetaProperties) MarshalJSON() ([]byte, error) {
+	type plain MetaProperties
+	return marshalWithExtras(plain(m), m.ExtraFields)
+}
+
+// UnmarshalJSON is part of json.Unmarshaler
+func (m *MetaProperties) UnmarshalJSON(data []byte) error {
+	type plain MetaProperties
+	var p plain
+	extras, err := unmarshalWithExtras(data, &p)
+	if err != nil {
+		return err
+	}
+	*m = MetaProperties(p)
+	m.ExtraFields = extras
+	return nil
+}
+
+// MarshalJSON is part of json.Marshaler
+func (e ListenEntry) MarshalJSON() ([]byte, error) {
+	type plain ListenEntry
+	return marshalWithExtras(plain(e), e.ExtraFields)
+}
+
+// UnmarshalJSON is part of json.Unmarshaler
+func (e *ListenEntry) UnmarshalJSON(data []byte) error {
+	type plain ListenEntry
+	var p plain
+	extras, err := unmarshalWithExtras(data, &p)
+	if err != nil {
+		return err
+	}
+	*e = ListenEntry(p)
+	e.ExtraFields = extras
+	return nil
+}
+
+// MarshalJSON is part of json.Marshaler
+func (e ConnectEntry) MarshalJSON() ([]byte, error) {
+	type plain ConnectEntry
+	return marshalWithExtras(plain(e), e.ExtraFields)
+}
+
+// UnmarshalJSON is part of json.Unmarshaler
+func (e *ConnectEntry) UnmarshalJSON(data []byte) error {
+	type plain ConnectEntry
+	var p plain
+	extras, err := unmarshalWithExtras(data, &p)
+	if err != nil {
+		return err
+	}
+	*e = ConnectEntry(p)
+	e.ExtraFields = extras
+	return nil
+}
+
+// marshalWithExtras encodes the struct v and adds the extra fields to the same object.
+// Fields of the struct take precedence over extra fields with the same name.
+func marshalWithExtras(v interface{}, extras map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extras) == 0 {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, each := range extras {
+		if _, ok := fields[k]; ok {
+			continue
+		}
+		raw, err := json.Marshal(normalizeKeys(each))
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = raw
+	}
+	return json.Marshal(fields)
+}
+
+// unmarshalWithExtras decodes data into the struct pointed to by v and returns all other fields.
+func unmarshalWithExtras(data []byte, v interface{}) (map[string]interface{}, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	fields := map[string]interface{}{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	for _, each := range jsonFieldNames(reflect.TypeOf(v).Elem()) {
+		delete(fields, each)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fromJSON(fields).(map[string]interface{}), nil
+}
+
+// jsonFieldNames returns the JSON keys of the fields of a struct type.
+func jsonFieldNames(t reflect.Type) (names []string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+// fromJSON converts numbers into int or float64 such that values are the same as when decoded from YAML.
+func fromJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, each := range t {
+			t[k] = fromJSON(each)
+		}
+	case []interface{}:
+		for i, each := range t {
+			t[i] = fromJSON(each)
+		}
+	}
+	return v
+}
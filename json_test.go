@@ -0,0 +1,86 @@
+package xconnect
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestJSONGolden(t *testing.T) {
+	for _, each := range []string{"spec-xconnect.yaml", "xconnect-extended.yaml"} {
+		d, err := ioutil.ReadFile(each)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc Document
+		if err := yaml.Unmarshal(d, &doc); err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden := filepath.Join("testdata", strings.TrimSuffix(each, ".yaml")+".golden.json")
+		if *update {
+			ioutil.WriteFile(golden, data, 0644)
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("%s: JSON differs from %s:\n%s", each, golden, string(data))
+		}
+		// JSON -> YAML must give the same document
+		var fromJSON Document
+		if err := json.Unmarshal(data, &fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		y, err := yaml.Marshal(fromJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var roundtrip Document
+		if err := yaml.Unmarshal(y, &roundtrip); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(doc, roundtrip) {
+			t.Errorf("%s: YAML -> JSON -> YAML is not lossless:\n%#v\n%#v", each, doc, roundtrip)
+		}
+	}
+}
+
+func TestJSONExtraFieldsFlattened(t *testing.T) {
+	port := 5432
+	e := ConnectEntry{
+		Protocol:    "jdbc",
+		Port:        &port,
+		ExtraFields: map[string]interface{}{"pool": map[interface{}]interface{}{"max": 10}, "port": 1},
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"pool":{"max":10},"port":5432,"protocol":"jdbc"}`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	var back ConnectEntry
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := back.ExtraFields["pool"].(map[string]interface{})["max"], 10; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(back.ExtraFields), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
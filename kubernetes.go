@@ -3,9 +3,12 @@ package xconnect
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"time"
 
 	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 /**
@@ -57,3 +60,58 @@ func (k K8SConfiguration) ExtractConfig() (x XConnect, err error) {
 	err = yaml.Unmarshal(encoded, &x)
 	return
 }
+
+// LoadK8S returns the document in the application.yml data of a Kubernetes ConfigMap file, see ExtractConfig.
+// Source positions refer to the lines of the ConfigMap file.
+func (l *Loader) LoadK8S(filename string) (Document, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Document{}, fmt.Errorf("unable to read:%v", err)
+	}
+	var root yaml3.Node
+	if err := yaml3.Unmarshal(content, &root); err != nil {
+		return Document{}, parseError(filename, err)
+	}
+	data := mappingValue(mappingValue(&root, "data"), "application.yml")
+	if data == nil || data.Kind != yaml3.ScalarNode {
+		return Document{}, errors.New("missing key: [application.yml]")
+	}
+	tree, err := parseTree([]byte(data.Value), filename)
+	if err != nil {
+		return Document{}, err
+	}
+	idx := positionIndex{}
+	// only the lines of a literal block scalar are the lines of the file
+	if data.Style&yaml3.LiteralStyle != 0 {
+		indent := blockIndent(content, lineOffsets(content), data.Line)
+		for k, each := range parsePositions([]byte(data.Value), filename) {
+			each.Line += data.Line
+			each.Column += indent
+			idx[k] = each
+		}
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return Document{}, err
+	}
+	if tree, err = includeAll(tree, filepath.Dir(abs), []string{abs + "#"}, idx); err != nil {
+		return Document{}, err
+	}
+	return l.build(tree, idx, nil)
+}
+
+// mappingValue returns the value of a key in a mapping node, or of the mapping in a document node ; nil if missing.
+func mappingValue(n *yaml3.Node, key string) *yaml3.Node {
+	if n != nil && n.Kind == yaml3.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if n == nil || n.Kind != yaml3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -43,3 +43,23 @@ func TestKubernetesSome(t *testing.T) {
 		t.Errorf("got [%s] want [%s]", got, want)
 	}
 }
+
+func TestLoadK8S(t *testing.T) {
+	l := NewLoader()
+	l.SkipSecrets = true
+	file := "kubernetes_configmap-application.properties.yml"
+	doc, err := l.LoadK8S(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.XConnect.Meta.Name, "account-service"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	p, ok := doc.Position("xconnect/listen/api")
+	if !ok {
+		t.Fatal("no position")
+	}
+	if got, want := p, (Position{File: file, Line: 19, Column: 9}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
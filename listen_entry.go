@@ -4,19 +4,24 @@ import "fmt"
 
 // ListenEntry is a list element in the xconnect.accept config.
 type ListenEntry struct {
-	Protocol string `yaml:"protocol,omitempty" json:"scheme,omitempty"`
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
 	Host     string `yaml:"host,omitempty" json:"host,omitempty"`
 	Port     *int   `yaml:"port,omitempty" json:"port,omitempty"`
 	// for database connection strings
-	URL         string                 `yaml:"url,omitempty" json:"url,omitempty"`
-	Secure      *bool                  `yaml:"secure,omitempty" json:"secure,omitempty"`
-	Disabled    bool                   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
-	ExtraFields map[string]interface{} `yaml:"-,inline"`
+	URL    string `yaml:"url,omitempty" json:"url,omitempty"`
+	Secure *bool  `yaml:"secure,omitempty" json:"secure,omitempty"`
+	// TLS is the material used if secure is true
+	TLS      *TLS   `yaml:"tls-config,omitempty" json:"tls-config,omitempty"`
+	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	Kind     string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	// Resource is to identify the virtual listen part, e.g. a topic that other services publish to
+	Resource    string                 `yaml:"resource,omitempty" json:"resource,omitempty"`
+	ExtraFields map[string]interface{} `yaml:"-,inline" json:"-"`
 }
 
 func (e ListenEntry) find(keys []string) (interface{}, bool) {
 	if len(keys) == 0 {
-		return nil, false
+		return e, true
 	}
 	switch keys[0] {
 	case "protocol":
@@ -26,6 +31,11 @@ func (e ListenEntry) find(keys []string) (interface{}, bool) {
 			return *e.Secure, true
 		}
 		return nil, false
+	case "tls-config":
+		if e.TLS != nil {
+			return e.TLS.find(keys[1:])
+		}
+		return nil, false
 	case "host":
 		return e.Host, true
 	case "port":
@@ -37,19 +47,48 @@ func (e ListenEntry) find(keys []string) (interface{}, bool) {
 		return e.URL, true
 	case "disabled":
 		return e.Disabled, true
+	case "kind":
+		return e.Kind, true
+	case "resource":
+		return e.Resource, true
 	default:
 		return findInMap(keys, e.ExtraFields)
 	}
 }
 
+// ResourceID returns NetworkID() or KIND:RESOURCE
+func (e ListenEntry) ResourceID() string {
+	if id := e.NetworkID(); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s:%s", e.Kind, e.Resource)
+}
+
+// NetworkID returns URL or HOST:PORT
 func (e ListenEntry) NetworkID() string {
 	// URL overrides Host+Port
 	if len(e.URL) != 0 {
 		return e.URL
 	}
-	p := 0
+	if len(e.Host) != 0 {
+		if p := e.EffectivePort(); p != 0 {
+			return fmt.Sprintf("%s:%d", e.Host, p)
+		}
+		return e.Host
+	}
+	// url empty, host empty, we dont know
+	return ""
+}
+
+// EffectivePort returns the port, or the port of the URL, or the default port of the protocol ; 0 if unknown.
+func (e ListenEntry) EffectivePort() int {
 	if e.Port != nil {
-		p = *e.Port
+		return *e.Port
 	}
-	return fmt.Sprintf("%s:%d", e.Host, p)
+	return e.Endpoint().Port
+}
+
+// EffectiveSecure returns the secure value, or whether the URL scheme implies it, or the default of the protocol.
+func (e ListenEntry) EffectiveSecure() bool {
+	return effectiveSecure(e.Protocol, e.Secure, urlScheme(e.URL))
 }
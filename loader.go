@@ -0,0 +1,130 @@
+package xconnect
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// Loader reads documents and resolves the secret references and encrypted values in them.
+// Use NewLoader to create one ; LoadConfig and GetConfig use a new Loader.
+type Loader struct {
+	// SkipSecrets is true if secret references and encrypted values must be kept as strings,
+	// e.g. by tools that do not need the secrets. Encrypted values of fields that are not strings, such as port, are left out.
+	SkipSecrets bool
+	// Key is the AES-256 key to decrypt ENC[...] values ; if nil then the file named by XCONNECT_KEY_FILE is used.
+	Key []byte
+	// KeepUnresolved is true if references such as ${ENV_VAR} that cannot be resolved are kept as text instead of being an error,
+	// e.g. by tools that do not run in the environment of the service.
+	KeepUnresolved bool
+	// LookupEnv returns the value of an environment variable for a reference ; if nil then os.LookupEnv is used.
+	// Tools can use NoEnv such that the environment of the machine they run on does not end up in the document.
+	LookupEnv func(name string) (string, bool)
+	// Strict is true if extra fields of listen and connect entries that are misspellings or legacy names
+	// of known fields are an error, see CheckExtraFields. The error is a *FieldsError with the error findings.
+	Strict bool
+
+	mutex     sync.RWMutex
+	resolvers map[string]SecretResolver
+}
+
+// NoEnv is a LookupEnv function for which no environment variable is set.
+func NoEnv(name string) (string, bool) { return "", false }
+
+// NewLoader returns a Loader with the resolvers file and env.
+func NewLoader() *Loader {
+	l := &Loader{resolvers: map[string]SecretResolver{}}
+	l.RegisterResolver("file", FileResolver{})
+	l.RegisterResolver("env", EnvResolver{})
+	return l
+}
+
+// RegisterResolver adds or replaces the resolver for secret://<name>/... references, e.g. cmd with CommandResolver.
+func (l *Loader) RegisterResolver(name string, r SecretResolver) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.resolvers[name] = r
+}
+
+// Load returns the document of a file, see LoadConfig.
+func (l *Loader) Load(filename string, overlays ...string) (Document, error) {
+	tree, idx, err := readTree(filename)
+	if err != nil {
+		return Document{}, err
+	}
+	return l.build(tree, idx, overlays)
+}
+
+// Get returns the document of the content of an environment variable or else of a file, see GetConfig.
+func (l *Loader) Get(envKey string, filename string, overlays ...string) (Document, error) {
+	content := os.Getenv(envKey)
+	if len(content) == 0 {
+		return l.Load(filename, overlays...)
+	}
+	// positions refer to the variable, e.g. $XCONNECT:12:3
+	source := "$" + envKey
+	tree, err := parseTree([]byte(content), source)
+	if err != nil {
+		return Document{}, err
+	}
+	idx := parsePositions([]byte(content), source)
+	if tree, err = includeAll(tree, ".", nil, idx); err != nil {
+		return Document{}, err
+	}
+	return l.build(tree, idx, overlays)
+}
+
+// build merges the overlay files into the tree, resolves all references and secrets and decodes the result.
+// The document keeps the positions of the index for all paths that are still in the tree.
+func (l *Loader) build(tree map[interface{}]interface{}, idx positionIndex, overlays []string) (Document, error) {
+	for _, each := range overlays {
+		overlay, positions, err := readTree(each)
+		if err != nil {
+			return Document{}, err
+		}
+		merged, ok := merge(tree, overlay).(map[interface{}]interface{})
+		if !ok {
+			return Document{}, fmt.Errorf("unable to merge overlay [%s], the root must be a map of keys", each)
+		}
+		idx.merge(positions, overlay, merged)
+		tree = merged
+	}
+	if l.SkipSecrets {
+		dropEncryptedFields(tree, reflect.TypeOf(Document{}))
+	}
+	idx.prune(tree)
+	if !l.SkipSecrets {
+		if _, err := decryptTree(tree, "", l.loaderKey); err != nil {
+			return Document{}, err
+		}
+	}
+	if err := interpolate(tree, l.LookupEnv); err != nil && !l.KeepUnresolved {
+		return Document{}, err
+	}
+	doc, err := decodeTree(tree, idx)
+	if err != nil {
+		return Document{}, err
+	}
+	doc.XConnect.positions = idx
+	if l.Strict {
+		errs := []Finding{}
+		for _, each := range doc.XConnect.CheckExtraFields() {
+			if each.Severity == SeverityError {
+				errs = append(errs, each)
+			}
+		}
+		if len(errs) > 0 {
+			return Document{}, &FieldsError{Findings: errs}
+		}
+	}
+	if l.SkipSecrets {
+		return doc, nil
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if err := resolveSecrets(&doc, l.resolvers); err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
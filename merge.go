@@ -0,0 +1,74 @@
+package xconnect
+
+import "reflect"
+
+// Overlay merge rules:
+//
+//   - maps, such as listen, connect and any extra fields, are merged key by key, recursively ;
+//   - a null value deletes the inherited entry, e.g. "some-cache: ~" ;
+//   - scalars and lists, such as meta.tags, replace the inherited value ;
+//   - a map with the single key "$append" appends its list to the inherited list ;
+//   - a map with the single key "$remove" removes its elements from the inherited list.
+const (
+	appendKey = "$append"
+	removeKey = "$remove"
+)
+
+// merge returns the result of applying the overlay to the base value.
+// Maps in base are modified.
+func merge(base, overlay interface{}) interface{} {
+	om, ok := overlay.(map[interface{}]interface{})
+	if !ok {
+		return overlay
+	}
+	if list, ok := listOperation(om, appendKey); ok {
+		inherited, _ := base.([]interface{})
+		return append(append([]interface{}{}, inherited...), list...)
+	}
+	if list, ok := listOperation(om, removeKey); ok {
+		inherited, _ := base.([]interface{})
+		kept := []interface{}{}
+		for _, each := range inherited {
+			if !containsValue(list, each) {
+				kept = append(kept, each)
+			}
+		}
+		return kept
+	}
+	bm, ok := base.(map[interface{}]interface{})
+	if !ok {
+		bm = map[interface{}]interface{}{}
+	}
+	for k, v := range om {
+		if v == nil {
+			delete(bm, k)
+			continue
+		}
+		bm[k] = merge(bm[k], v)
+	}
+	return bm
+}
+
+// listOperation returns the list for a map that has only the operation key.
+func listOperation(m map[interface{}]interface{}, key string) ([]interface{}, bool) {
+	if len(m) != 1 {
+		return nil, false
+	}
+	v, ok := m[key]
+	if !ok {
+		return nil, false
+	}
+	if list, ok := v.([]interface{}); ok {
+		return list, true
+	}
+	return []interface{}{v}, true
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, each := range list {
+		if reflect.DeepEqual(each, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package xconnect
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mergeBase = `
+xconnect:
+  meta:
+    name: account-service
+    tags: [account, registration, search]
+  listen:
+    api:
+      host: localhost
+      port: 9443
+  connect:
+    some-db:
+      url: jdbc:postgresql://localhost:5432/postgres
+      pool:
+        max: 10
+        min: 1
+    some-cache:
+      host: localhost
+      port: 6379
+`
+
+const mergeProd = `
+xconnect:
+  meta:
+    tags:
+      $append: [prod]
+  listen:
+    api:
+      host: account-service.net
+  connect:
+    some-db:
+      url: jdbc:postgresql://prod-db:5432/accounts
+      pool:
+        max: 50
+    some-cache: ~
+    events:
+      kind: gcp.pubsub
+      resource: accounts
+`
+
+const mergeEU = `
+xconnect:
+  meta:
+    tags:
+      $remove: search
+`
+
+func writeTempFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "xconnect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		full := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(full), os.ModePerm)
+		if err := ioutil.WriteFile(full, []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigOverlays(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"xconnect.yaml":      mergeBase,
+		"xconnect.prod.yaml": mergeProd,
+		"xconnect.eu.yaml":   mergeEU,
+	})
+	defer os.RemoveAll(dir)
+	doc, err := LoadConfig(filepath.Join(dir, "xconnect.yaml"), filepath.Join(dir, "xconnect.prod.yaml"), filepath.Join(dir, "xconnect.eu.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := doc.XConnect
+	if got, want := x.Meta.Name, "account-service"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := x.Meta.Labels, []string{"account", "registration", "prod"}; len(got) != 3 || got[2] != want[2] || got[1] != want[1] {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := x.Listen["api"].Host, "account-service.net"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := *x.Listen["api"].Port, 9443; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := x.Connect["some-db"].URL, "jdbc:postgresql://prod-db:5432/accounts"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustInt("xconnect/connect/some-db/pool/max"), 50; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustInt("xconnect/connect/some-db/pool/min"), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := x.Connect["some-cache"]; ok {
+		t.Error("some-cache should be deleted")
+	}
+	if got, want := x.Connect["events"].Kind, "gcp.pubsub"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestLoadConfigMissingOverlay(t *testing.T) {
+	if _, err := LoadConfig("spec-xconnect.yaml", "missing.yaml"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestLoadConfigListOverlay(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"append.yaml": "$append: [a]\n"})
+	defer os.RemoveAll(dir)
+	if _, err := LoadConfig("spec-xconnect.yaml", filepath.Join(dir, "append.yaml")); err == nil {
+		t.Error("error expected")
+	}
+}
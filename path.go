@@ -0,0 +1,158 @@
+package xconnect
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Paths are keys separated by a slash, e.g. xconnect/connect/db/url .
+// A key for a list is the index of an element, e.g. xconnect/meta/tags/0 .
+// A key that is a single star matches all keys on that level (FindAll only), e.g. xconnect/connect/*/kind .
+// A backslash escapes the next character so keys can contain a slash, star or backslash, e.g. xconnect/connect/db/a\/b .
+
+const (
+	pathEscape   = '\\'
+	pathWildcard = "*"
+)
+
+// splitPath returns the unescaped keys of a path.
+func splitPath(path string) []string {
+	keys, _ := parsePattern(path)
+	return keys
+}
+
+// parsePattern returns the unescaped keys of a path and, for each key, whether it is an unescaped wildcard.
+func parsePattern(path string) (keys []string, wildcards []bool) {
+	var key strings.Builder
+	escaped, hasEscape := false, false
+	flush := func() {
+		k := key.String()
+		keys = append(keys, k)
+		wildcards = append(wildcards, k == pathWildcard && !hasEscape)
+		key.Reset()
+		hasEscape = false
+	}
+	for _, r := range path {
+		switch {
+		case escaped:
+			key.WriteRune(r)
+			escaped = false
+		case r == pathEscape:
+			escaped, hasEscape = true, true
+		case string(r) == extraPathSeparator:
+			flush()
+		default:
+			key.WriteRune(r)
+		}
+	}
+	flush()
+	return
+}
+
+// escapeKey returns the key such that it can be used in a path.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if r == pathEscape || string(r) == extraPathSeparator || (string(r) == pathWildcard && key == pathWildcard) {
+			b.WriteRune(pathEscape)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// joinKeys returns the path of (unescaped) keys.
+func joinKeys(keys []string) string {
+	escaped := make([]string, len(keys))
+	for i, each := range keys {
+		escaped[i] = escapeKey(each)
+	}
+	return strings.Join(escaped, extraPathSeparator)
+}
+
+// Match is a value found by FindAll together with its concrete path.
+type Match struct {
+	Path  string
+	Value interface{}
+}
+
+// FindAll returns all values for a slash path that can have wildcards, e.g. xconnect/connect/*/kind .
+// Nil and empty string values are not included.
+func (d Document) FindAll(pattern string) []Match {
+	return FindAll(d, pattern)
+}
+
+// FindAll returns all values for a slash path that can have wildcards.
+// Nil and empty string values are not included.
+func FindAll(f finder, pattern string) []Match {
+	keys, wildcards := parsePattern(pattern)
+	matches := []Match{}
+	findAll(f, []string{}, keys, wildcards, &matches)
+	return matches
+}
+
+func findAll(f finder, prefix []string, keys []string, wildcards []bool, matches *[]Match) {
+	if len(keys) == 0 {
+		v, ok := f.find(prefix)
+		if !ok || v == nil || v == "" {
+			return
+		}
+		*matches = append(*matches, Match{Path: joinKeys(prefix), Value: v})
+		return
+	}
+	if !wildcards[0] {
+		findAll(f, append(prefix[:len(prefix):len(prefix)], keys[0]), keys[1:], wildcards[1:], matches)
+		return
+	}
+	parent, ok := f.find(prefix)
+	if !ok {
+		return
+	}
+	for _, each := range childKeys(parent) {
+		findAll(f, append(prefix[:len(prefix):len(prefix)], each), keys[1:], wildcards[1:], matches)
+	}
+}
+
+// childKeys returns the keys of a map, the indices of a list or the non-empty fields of a struct.
+func childKeys(v interface{}) (keys []string) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		for _, each := range rv.MapKeys() {
+			keys = append(keys, toKey(each.Interface()))
+		}
+		sort.Strings(keys)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			keys = append(keys, strconv.Itoa(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			name, inline := yamlFieldName(rv.Type().Field(i))
+			if inline {
+				keys = append(keys, childKeys(rv.Field(i).Interface())...)
+				continue
+			}
+			if name != "" && !rv.Field(i).IsZero() {
+				keys = append(keys, name)
+			}
+		}
+	}
+	return
+}
+
+func toKey(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	s, _ := toString(k)
+	return s
+}
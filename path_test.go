@@ -0,0 +1,76 @@
+package xconnect
+
+import "testing"
+
+func TestPathListIndex(t *testing.T) {
+	doc, err := LoadConfig("testdata/path.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustString("xconnect/meta/tags/1"), "search"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustString("xconnect/connect/db/hosts/1/name"), "replica"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, err := doc.FindString("xconnect/meta/tags/2"); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := doc.FindString("xconnect/meta/tags/first"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestPathEscape(t *testing.T) {
+	doc, err := LoadConfig("testdata/path.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustString(`xconnect/connect/db/a\/b`), "slashed"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustString(`xconnect/connect/db/\*`), "star"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := joinKeys([]string{"a/b", "*", `c\d`}), `a\/b/\*/c\\d`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := splitPath(`a\/b/\*/c\\d`), []string{"a/b", "*", `c\d`}; len(got) != 3 || got[0] != want[0] || got[2] != want[2] {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	doc, err := LoadConfig("testdata/path.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := doc.FindAll("xconnect/connect/*/kind")
+	if got, want := len(matches), 2; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, matches)
+	}
+	if got, want := matches[0].Path, "xconnect/connect/cache/kind"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := matches[1].Value, "postgres"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	matches = doc.FindAll("xconnect/connect/db/hosts/*/name")
+	if got, want := len(matches), 2; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, matches)
+	}
+	if got, want := matches[1].Path, "xconnect/connect/db/hosts/1/name"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	matches = doc.FindAll("xconnect/meta/tags/*")
+	if got, want := len(matches), 2; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, matches)
+	}
+	matches = doc.FindAll("xconnect/connect/db/*")
+	if got, want := len(matches), 4; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, matches)
+	}
+	if got, want := matches[1].Path, `xconnect/connect/db/\*`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
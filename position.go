@@ -0,0 +1,239 @@
+package xconnect
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Position is the location of a key or list element in a source file.
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// IsZero returns true if the position is unknown.
+func (p Position) IsZero() bool {
+	return p.Line == 0
+}
+
+// String returns file:line:column, or line:column if the file is unknown.
+func (p Position) String() string {
+	if len(p.File) == 0 {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// positionIndex maps the slash path of each key and list element of a document to its position.
+type positionIndex map[string]Position
+
+// positioner is a finder that knows the positions of its values.
+type positioner interface {
+	// position returns the position of the value at the keys, or else of its nearest parent with a position.
+	position(keys []string) (Position, bool)
+}
+
+// parsePositions returns the positions of all keys and list elements in YAML content.
+// Values are parsed with yaml.v2 ; the nodes of yaml.v3 are only used for their line and column.
+func parsePositions(content []byte, file string) positionIndex {
+	idx := positionIndex{}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		// errors are reported when parsing the values
+		return idx
+	}
+	idx.addNode(&root, "", file, true)
+	return idx
+}
+
+// addNode adds the positions of all keys and list elements in the node at a path.
+// If override is false then existing positions are kept, e.g. for keys of a << merge.
+func (idx positionIndex) addNode(n *yaml.Node, path, file string, override bool) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, each := range n.Content {
+			idx.addNode(each, path, file, override)
+		}
+	case yaml.AliasNode:
+		idx.addNode(n.Alias, path, file, override)
+	case yaml.MappingNode:
+		merges := []*yaml.Node{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				merges = append(merges, v)
+				continue
+			}
+			p := joinPath(path, escapeKey(k.Value))
+			idx.set(p, Position{File: file, Line: k.Line, Column: k.Column}, override)
+			idx.addNode(v, p, file, override)
+		}
+		// keys of the mapping itself take precedence
+		for _, each := range merges {
+			if each.Kind == yaml.SequenceNode {
+				for _, m := range each.Content {
+					idx.addNode(m, path, file, false)
+				}
+				continue
+			}
+			idx.addNode(each, path, file, false)
+		}
+	case yaml.SequenceNode:
+		for i, each := range n.Content {
+			p := joinPath(path, strconv.Itoa(i))
+			idx.set(p, Position{File: file, Line: each.Line, Column: each.Column}, override)
+			idx.addNode(each, p, file, override)
+		}
+	}
+}
+
+func (idx positionIndex) set(path string, p Position, override bool) {
+	if _, ok := idx[path]; ok && !override {
+		return
+	}
+	idx[path] = p
+}
+
+// merge adds all positions of an overlay, using the overlay tree and the merged tree:
+// maps that are merged key by key keep their positions ; all other values, including the list of
+// an $append or $remove key, get the position in the overlay.
+func (idx positionIndex) merge(overlay positionIndex, tree, merged interface{}) {
+	for k, each := range overlay {
+		keys := splitPath(k)
+		v, _ := findInValue(keys, tree)
+		_, inOverlay := v.(map[interface{}]interface{})
+		m, _ := findInValue(keys, merged)
+		_, inMerged := m.(map[interface{}]interface{})
+		idx.set(k, each, !(inOverlay && inMerged))
+	}
+}
+
+// include adds the positions of an included file at path to, for all its paths below from.
+// Existing positions are kept because the including document takes precedence.
+func (idx positionIndex) include(included positionIndex, from, to string) {
+	for k, each := range included {
+		rel := k
+		if len(from) > 0 {
+			if k == from {
+				rel = ""
+			} else if strings.HasPrefix(k, from+extraPathSeparator) {
+				rel = k[len(from)+1:]
+			} else {
+				continue
+			}
+		}
+		path := to
+		if len(rel) > 0 {
+			path = joinPath(to, rel)
+		}
+		idx.set(path, each, false)
+	}
+}
+
+// prune removes the positions of paths that are not in the tree, e.g. deleted by an overlay or an $include key.
+func (idx positionIndex) prune(tree interface{}) {
+	for k := range idx {
+		if _, ok := findInValue(splitPath(k), tree); !ok {
+			delete(idx, k)
+		}
+	}
+}
+
+// nearest returns the position of the keys or else of the nearest parent with a position.
+func (idx positionIndex) nearest(keys []string) (Position, bool) {
+	for i := len(keys); i > 0; i-- {
+		if p, ok := idx[joinKeys(keys[:i])]; ok {
+			return p, true
+		}
+	}
+	return Position{}, false
+}
+
+// Position returns the source position of the value at a slash path, e.g. xconnect/connect/some-db .
+func (d Document) Position(path string) (Position, bool) {
+	p, ok := d.XConnect.positions[joinKeys(splitPath(path))]
+	return p, ok
+}
+
+func (d Document) position(keys []string) (Position, bool) {
+	return d.XConnect.positions.nearest(keys)
+}
+
+// Position returns the source position of the value at a slash path in this section, e.g. connect/some-db .
+func (x XConnect) Position(path string) (Position, bool) {
+	return Document{XConnect: x}.Position("xconnect" + extraPathSeparator + path)
+}
+
+func (x XConnect) position(keys []string) (Position, bool) {
+	return x.positions.nearest(append([]string{"xconnect"}, keys...))
+}
+
+// locate sets the position of each finding, using the position of its path or else of its nearest parent.
+func (idx positionIndex) locate(list []Finding) {
+	for i, each := range list {
+		list[i].Position, _ = idx.nearest(splitPath(each.Path))
+	}
+}
+
+// yamlLinePattern matches the line in an error of the YAML parser.
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// typeErrorLinePattern matches the line of each error in a *yaml.TypeError.
+var typeErrorLinePattern = regexp.MustCompile(`^line (\d+): `)
+
+// decodeError returns the error of decoding data, the YAML of a tree, with the source positions of the values in the index,
+// e.g. xconnect.yaml:12:7: cannot unmarshal !!str `abc` into int .
+func (idx positionIndex) decodeError(data []byte, err error) error {
+	terr, ok := err.(*yaml2.TypeError)
+	if !ok {
+		return fmt.Errorf("unable to unmarshal YAML:%v", err)
+	}
+	// the deepest path on each line of the data
+	paths := map[int]string{}
+	for path, each := range parsePositions(data, "") {
+		if other, ok := paths[each.Line]; !ok || len(path) > len(other) {
+			paths[each.Line] = path
+		}
+	}
+	list := []string{}
+	for _, each := range terr.Errors {
+		m := typeErrorLinePattern.FindStringSubmatch(each)
+		if m == nil {
+			list = append(list, each)
+			continue
+		}
+		line, _ := strconv.Atoi(m[1])
+		message := each[len(m[0]):]
+		path, ok := paths[line]
+		if !ok {
+			list = append(list, message)
+			continue
+		}
+		// a map or list starts on the line of its first key or element
+		if strings.HasPrefix(message, "cannot unmarshal !!map") || strings.HasPrefix(message, "cannot unmarshal !!seq") {
+			if keys := splitPath(path); len(keys) > 1 {
+				path = joinKeys(keys[:len(keys)-1])
+			}
+		}
+		if p, ok := idx[path]; ok {
+			list = append(list, fmt.Sprintf("%s: %s at [%s]", p, message, path))
+		} else {
+			list = append(list, fmt.Sprintf("%s at [%s]", message, path))
+		}
+	}
+	return fmt.Errorf("unable to unmarshal YAML:%s", strings.Join(list, "; "))
+}
+
+// parseError returns the error of the YAML parser with the file name, e.g. xconnect.yaml:3: did not find expected key .
+func parseError(file string, err error) error {
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		return fmt.Errorf("unable to unmarshal YAML:%s:%s: %s", file, m[1], err.Error()[len(m[0]):])
+	}
+	return fmt.Errorf("unable to unmarshal YAML:%s: %v", file, err)
+}
@@ -0,0 +1,171 @@
+package xconnect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPositions(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"xconnect.yaml": `xconnect:
+  meta:
+    name: shop
+    tags: [a, b]
+  connect:
+    $include: shared/datastores.yaml
+    cache:
+      host: cache
+`,
+		"shared/datastores.yaml": `some-db:
+  url: postgres://db:5432/shop
+`,
+		"xconnect.append.yaml": `xconnect:
+  meta:
+    tags:
+      $append: [c]
+`,
+		"xconnect.prod.yaml": `xconnect:
+  meta:
+    tags: [prod]
+  connect:
+    cache:
+      port: 6379
+`,
+	})
+	defer os.RemoveAll(dir)
+	main, shared, prod := filepath.Join(dir, "xconnect.yaml"), filepath.Join(dir, "shared", "datastores.yaml"), filepath.Join(dir, "xconnect.prod.yaml")
+	doc, err := LoadConfig(main, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []struct {
+		path string
+		pos  Position
+	}{
+		{"xconnect/meta/name", Position{File: main, Line: 3, Column: 5}},
+		{"xconnect/connect/cache", Position{File: main, Line: 7, Column: 5}},
+		{"xconnect/connect/cache/port", Position{File: prod, Line: 6, Column: 7}},
+		{"xconnect/connect/some-db/url", Position{File: shared, Line: 2, Column: 3}},
+		{"xconnect/meta/tags/0", Position{File: prod, Line: 3, Column: 12}},
+	} {
+		p, ok := doc.Position(each.path)
+		if !ok {
+			t.Errorf("no position for [%s]", each.path)
+			continue
+		}
+		if got, want := p, each.pos; got != want {
+			t.Errorf("%s: got [%v] want [%v]", each.path, got, want)
+		}
+	}
+	// a list operation of an overlay is located in that overlay
+	appended := filepath.Join(dir, "xconnect.append.yaml")
+	appendDoc, err := LoadConfig(main, appended)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := appendDoc.MustStringSlice("xconnect/meta/tags"), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if p, _ := appendDoc.Position("xconnect/meta/tags"); p != (Position{File: appended, Line: 3, Column: 5}) {
+		t.Errorf("got [%v] want [%v]", p, Position{File: appended, Line: 3, Column: 5})
+	}
+	// replaced by the overlay or not a value
+	for _, each := range []string{"xconnect/meta/tags/1", "xconnect/connect/$include"} {
+		if _, ok := doc.Position(each); ok {
+			t.Errorf("unexpected position for [%s]", each)
+		}
+	}
+	if got, want := doc.XConnect.Redacted().Connect["cache"].Host, "cache"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := doc.XConnect.Redacted().Position("connect/cache"); !ok {
+		t.Error("redacted section has no positions")
+	}
+
+	_, err = doc.FindString("xconnect/connect/some-dbx/url")
+	var nerr *NotFoundError
+	if !errors.As(err, &nerr) {
+		t.Fatalf("got [%T] want *NotFoundError", err)
+	}
+	if got, want := nerr.Position, (Position{File: main, Line: 5, Column: 3}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := err.Error(), main+":5:3: unable to find string at [xconnect/connect/some-dbx/url]: no [some-dbx] in [xconnect/connect]"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	_, err = doc.FindBool("xconnect/connect/some-db/url")
+	var merr *TypeMismatchError
+	if !errors.As(err, &merr) {
+		t.Fatalf("got [%T] want *TypeMismatchError", err)
+	}
+	if got, want := merr.Position.Line, 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestFindingPositions(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"xconnect.yaml": `xconnect:
+  meta:
+    version: 1
+  connect:
+    db:
+      port: 99999
+`})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "xconnect.yaml")
+	doc, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := doc.Validate()
+	if got, want := len(list), 3; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	// missing name is reported at its parent
+	if got, want := list[0].String(), file+":2:3: error: xconnect/meta/name: missing name"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := list[1].Position.Line, 6; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	schema, err := ValidateSchema([]byte("xconnect:\n  meta:\n    name: shop\n  listen:\n    api:\n      port: high\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) == 0 {
+		t.Fatal("finding expected")
+	}
+	if got, want := schema[0].Position.String(), "6:7"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"broken.yaml": "xconnect:\n  meta: [\n"})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "broken.yaml")
+	_, err := LoadConfig(file)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if got, want := err.Error(), "unable to unmarshal YAML:"+file+":2: "; !strings.HasPrefix(got, want) {
+		t.Errorf("got [%v] want prefix [%v]", got, want)
+	}
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"xconnect.yaml": "# shop\n\nxconnect:\n  connect:\n    db:\n      host: db\n      port: abc\n"})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "xconnect.yaml")
+	_, err := LoadConfig(file)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if got, want := err.Error(), "unable to unmarshal YAML:"+file+":7:7: cannot unmarshal !!str `abc` into int at [xconnect/connect/db/port]"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package xconnect
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protocol describes a value for the protocol field, with its defaults.
+type Protocol struct {
+	// Name is the lowercase value used in the protocol field.
+	Name string
+	// Port is the default port, 0 if there is none.
+	Port int
+	// SecurePort is the default port if secure is true, 0 if there is none.
+	SecurePort int
+	// Secure is the default for the secure field.
+	Secure bool
+	// Aliases are other names or URL schemes for this protocol, e.g. postgresql for postgres.
+	Aliases []string
+	// SecureAliases are URL schemes that imply secure is true, e.g. https for http.
+	SecureAliases []string
+	// Tuning has the defaults for the tuning fields of a connect entry.
+	Tuning Tuning
+}
+
+// DefaultPort returns the default port, 0 if there is none.
+func (p Protocol) DefaultPort(secure bool) int {
+	if secure {
+		return p.SecurePort
+	}
+	return p.Port
+}
+
+// IsSecureAlias returns true if the name is a URL scheme that implies secure, e.g. https .
+func (p Protocol) IsSecureAlias(name string) bool {
+	name = strings.ToLower(name)
+	for _, each := range p.SecureAliases {
+		if each == name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	protocolsMutex sync.RWMutex
+	// protocols has an entry for each name and alias.
+	protocols = map[string]Protocol{}
+)
+
+// Default tuning values by kind of protocol.
+var (
+	requestTuning  = Tuning{ConnectTimeout: 5 * time.Second, RequestTimeout: 30 * time.Second, IdleTimeout: 90 * time.Second}
+	streamTuning   = Tuning{ConnectTimeout: 5 * time.Second}
+	databaseTuning = Tuning{ConnectTimeout: 5 * time.Second, IdleTimeout: 5 * time.Minute}
+	cacheTuning    = Tuning{ConnectTimeout: time.Second, RequestTimeout: 3 * time.Second}
+	brokerTuning   = Tuning{ConnectTimeout: 10 * time.Second}
+)
+
+func init() {
+	for _, each := range []Protocol{
+		{Name: "http", Port: 80, SecurePort: 443, SecureAliases: []string{"https"}, Tuning: requestTuning},
+		{Name: "http2", Port: 80, SecurePort: 443, Aliases: []string{"h2c"}, SecureAliases: []string{"h2"}, Tuning: requestTuning},
+		{Name: "grpc", Port: 80, SecurePort: 443, SecureAliases: []string{"grpcs"}, Tuning: streamTuning},
+		{Name: "tcp"},
+		{Name: "jdbc", Tuning: databaseTuning},
+		{Name: "postgres", Port: 5432, SecurePort: 5432, Aliases: []string{"postgresql", "pgx"}, Tuning: databaseTuning},
+		{Name: "mysql", Port: 3306, SecurePort: 3306, Aliases: []string{"mariadb"}, Tuning: databaseTuning},
+		{Name: "sqlserver", Port: 1433, SecurePort: 1433, Aliases: []string{"mssql"}, Tuning: databaseTuning},
+		{Name: "redis", Port: 6379, SecurePort: 6379, SecureAliases: []string{"rediss"}, Tuning: cacheTuning},
+		{Name: "memcached", Port: 11211, Tuning: cacheTuning},
+		{Name: "mongodb", Port: 27017, SecurePort: 27017, Aliases: []string{"mongodb+srv", "mongo"}, Tuning: databaseTuning},
+		{Name: "amqp", Port: 5672, SecurePort: 5671, SecureAliases: []string{"amqps"}, Tuning: brokerTuning},
+		{Name: "kafka", Port: 9092, SecurePort: 9093, Tuning: brokerTuning},
+		{Name: "nats", Port: 4222, SecurePort: 4222, SecureAliases: []string{"tls"}, Tuning: brokerTuning},
+		{Name: "mqtt", Port: 1883, SecurePort: 8883, SecureAliases: []string{"mqtts"}, Tuning: brokerTuning},
+		{Name: "cassandra", Port: 9042, SecurePort: 9042, Tuning: databaseTuning},
+		{Name: "elasticsearch", Port: 9200, SecurePort: 9200, Aliases: []string{"elastic"}, Tuning: requestTuning},
+		{Name: "ldap", Port: 389, SecurePort: 636, SecureAliases: []string{"ldaps"}, Tuning: streamTuning},
+		{Name: "smtp", Port: 25, SecurePort: 465, SecureAliases: []string{"smtps"}, Tuning: streamTuning},
+		{Name: "ssh", Port: 22, SecurePort: 22, Secure: true, Aliases: []string{"sftp"}, Tuning: streamTuning},
+	} {
+		RegisterProtocol(each)
+	}
+}
+
+// RegisterProtocol adds or replaces a protocol, by its name and aliases.
+// Applications can use this to add their own protocols.
+func RegisterProtocol(p Protocol) {
+	protocolsMutex.Lock()
+	defer protocolsMutex.Unlock()
+	p.Name = strings.ToLower(p.Name)
+	protocols[p.Name] = p
+	for _, each := range append(append([]string{}, p.Aliases...), p.SecureAliases...) {
+		protocols[strings.ToLower(each)] = p
+	}
+}
+
+// LookupProtocol returns the protocol for a name, alias or URL scheme.
+func LookupProtocol(name string) (Protocol, bool) {
+	protocolsMutex.RLock()
+	defer protocolsMutex.RUnlock()
+	p, ok := protocols[strings.ToLower(name)]
+	return p, ok
+}
+
+// ProtocolNames returns the sorted names of all registered protocols, without aliases.
+func ProtocolNames() (names []string) {
+	protocolsMutex.RLock()
+	defer protocolsMutex.RUnlock()
+	for k, each := range protocols {
+		if k == each.Name {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// effectiveSecure returns the value of secure if set, or else whether the scheme implies it, or else the protocol default.
+func effectiveSecure(protocol string, secure *bool, scheme string) bool {
+	if secure != nil {
+		return *secure
+	}
+	if scheme != "" {
+		if p, ok := LookupProtocol(scheme); ok {
+			return p.IsSecureAlias(scheme) || p.Secure
+		}
+	}
+	if p, ok := LookupProtocol(protocol); ok {
+		return p.IsSecureAlias(protocol) || p.Secure
+	}
+	return false
+}
+
+// defaultPort returns the default port for a protocol or scheme, 0 if unknown.
+func defaultPort(protocolOrScheme string, secure bool) int {
+	if p, ok := LookupProtocol(protocolOrScheme); ok {
+		return p.DefaultPort(secure)
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package xconnect
+
+import "testing"
+
+func TestLookupProtocol(t *testing.T) {
+	p, ok := LookupProtocol("PostgreSQL")
+	if !ok {
+		t.Fatal("postgresql alias expected")
+	}
+	if got, want := p.Name, "postgres"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	p, _ = LookupProtocol("amqps")
+	if got, want := p.IsSecureAlias("amqps"), true; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := p.DefaultPort(true), 5671; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := LookupProtocol("carrier-pigeon"); ok {
+		t.Error("unknown protocol expected")
+	}
+}
+
+func TestRegisterProtocol(t *testing.T) {
+	RegisterProtocol(Protocol{Name: "Gopher", Port: 70, Aliases: []string{"gopher+"}})
+	defer func() {
+		protocolsMutex.Lock()
+		delete(protocols, "gopher")
+		delete(protocols, "gopher+")
+		protocolsMutex.Unlock()
+	}()
+	e := ConnectEntry{Protocol: "gopher", Host: "floodgap.com"}
+	if got, want := e.NetworkID(), "floodgap.com:70"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(validateConnect(e)), 0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestEffectivePortAndSecure(t *testing.T) {
+	yes := true
+	for i, each := range []struct {
+		entry  ConnectEntry
+		port   int
+		secure bool
+		id     string
+	}{
+		{ConnectEntry{Protocol: "http", Host: "svc"}, 80, false, "svc:80"},
+		{ConnectEntry{Protocol: "http", Host: "svc", Secure: &yes}, 443, true, "svc:443"},
+		{ConnectEntry{Protocol: "redis", Host: "cache"}, 6379, false, "cache:6379"},
+		{ConnectEntry{Protocol: "tcp", Host: "raw"}, 0, false, "raw"},
+		{ConnectEntry{Protocol: "ssh", Host: "box"}, 22, true, "box:22"},
+		{ConnectEntry{URL: "rediss://cache"}, 6379, true, "rediss://cache"},
+		{ConnectEntry{URL: "amqps://broker/vhost"}, 5671, true, "amqps://broker/vhost"},
+	} {
+		if got, want := each.entry.EffectivePort(), each.port; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+		if got, want := each.entry.EffectiveSecure(), each.secure; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+		if got, want := each.entry.NetworkID(), each.id; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+}
+
+func TestValidateSecurePortMismatch(t *testing.T) {
+	port443, port80 := 443, 80
+	yes := true
+	e := ConnectEntry{Protocol: "http", Host: "svc", Port: &port443}
+	list := validateConnect(e)
+	if got, want := len(list), 1; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	if got, want := list[0].Severity, SeverityWarning; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	e = ConnectEntry{Protocol: "http", Host: "svc", Port: &port80, Secure: &yes}
+	if got, want := len(validateConnect(e)), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	e = ConnectEntry{Protocol: "http", Host: "svc", Port: &port443, Secure: &yes}
+	if got, want := len(validateConnect(e)), 0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+// validateConnect returns the findings of a connection with only this entry.
+func validateConnect(e ConnectEntry) []Finding {
+	x := XConnect{Meta: MetaProperties{Name: "test"}, Connect: map[string]ConnectEntry{"test": e}}
+	return x.Validate()
+}
@@ -0,0 +1,113 @@
+package xconnect
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces a credential.
+const redactedValue = "xxxxx"
+
+// sensitiveNames are parts of field and parameter names whose values are credentials.
+var sensitiveNames = []string{"password", "passwd", "pwd", "secret", "token", "apikey", "accesskey", "privatekey", "credential"}
+
+var (
+	// userinfoPattern matches the password of user:password@ in a URL.
+	userinfoPattern = regexp.MustCompile(`(://[^/?#;@\s:]*:)([^@/?#;\s]+)(@)`)
+	// paramPattern matches key=value in a query, JDBC parameters or a key=value connection string.
+	paramPattern = regexp.MustCompile(`((?:^|[?&;\s])([\w.-]+)=)('[^']*'|[^&;\s]*)`)
+)
+
+// isSensitiveName returns true if the value of a field or parameter with this name is a credential.
+func isSensitiveName(name string) bool {
+	n := strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(name))
+	for _, each := range sensitiveNames {
+		if strings.Contains(n, each) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns the text with credentials masked: the password of user:password@ in URLs
+// and the values of parameters with sensitive names, e.g. password=secret .
+func Redact(s string) string {
+	s = userinfoPattern.ReplaceAllString(s, "${1}"+redactedValue+"${3}")
+	return paramPattern.ReplaceAllStringFunc(s, func(match string) string {
+		m := paramPattern.FindStringSubmatch(match)
+		if !isSensitiveName(m[2]) {
+			return match
+		}
+		return m[1] + redactedValue
+	})
+}
+
+// Redacted returns a copy with all credentials masked, e.g. before writing it to a file or sending it to a service.
+// Credentials are the password in URLs, password query and JDBC parameters and the values of extra fields
+// with sensitive names such as password, token or api-key. Secret references such as env://DB_PASSWORD are kept.
+func (x XConnect) Redacted() XConnect {
+	r := x
+	r.ExtraFields = redactValue("", x.ExtraFields).(map[string]interface{})
+	r.Meta.ExtraFields = redactValue("", x.Meta.ExtraFields).(map[string]interface{})
+	if x.Listen != nil {
+		r.Listen = map[string]ListenEntry{}
+		for k, each := range x.Listen {
+			each.URL = Redact(each.URL)
+			each.ExtraFields = redactValue("", each.ExtraFields).(map[string]interface{})
+			r.Listen[k] = each
+		}
+	}
+	if x.Connect != nil {
+		r.Connect = map[string]ConnectEntry{}
+		for k, each := range x.Connect {
+			each.URL = Redact(each.URL)
+			each.ExtraFields = redactValue("", each.ExtraFields).(map[string]interface{})
+			r.Connect[k] = each
+		}
+	}
+	return r
+}
+
+// redactValue returns a copy of a value of a field with this name, with all credentials masked.
+func redactValue(name string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if t == nil {
+			return t
+		}
+		m := make(map[string]interface{}, len(t))
+		for k, each := range t {
+			m[k] = redactValue(k, each)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(t))
+		for k, each := range t {
+			m[k] = redactValue(toKey(k), each)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, each := range t {
+			a[i] = redactValue(name, each)
+		}
+		return a
+	case Secret:
+		// written as its reference
+		return t
+	case string:
+		if _, _, ok := parseSecretRef(t); ok || IsEncrypted(t) {
+			return t
+		}
+		if isSensitiveName(name) {
+			return redactedValue
+		}
+		return Redact(t)
+	case nil:
+		return nil
+	}
+	if isSensitiveName(name) {
+		return redactedValue
+	}
+	return v
+}
@@ -0,0 +1,267 @@
+package xconnect
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	schemaID    = "https://github.com/emicklei/xconnect/xconnect.schema.json"
+)
+
+// schemaConstraints returns extra keywords for fields, by YAML name, that cannot be derived from the Go type.
+func schemaConstraints() map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		"port": {
+			"minimum": 1,
+			"maximum": 65535,
+		},
+		"protocol": {
+			"enum": ProtocolNames(),
+		},
+		"min-version": {
+			"enum": []string{"1.0", "1.1", "1.2", "1.3"},
+		},
+	}
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of a Document.
+// It is derived from the YAML struct tags of the Go types.
+func JSONSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	root := schemaOf(reflect.TypeOf(Document{}), defs)
+	root["$schema"] = schemaDraft
+	root["$id"] = schemaID
+	root["title"] = "xconnect document"
+	root["$defs"] = defs
+	return root
+}
+
+// JSONSchemaBytes returns the indented JSON encoding of JSONSchema().
+func JSONSchemaBytes() []byte {
+	data, _ := json.MarshalIndent(JSONSchema(), "", "  ")
+	return append(data, '\n')
+}
+
+// schemaOf returns the schema for a Go type ; struct types are added to defs and referenced.
+func schemaOf(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if t == reflect.TypeOf(Duration(0)) {
+		// a string such as 5s or a number of milliseconds
+		return map[string]interface{}{"type": []string{"string", "integer"}}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), defs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = true // placeholder to stop recursion
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	// interface{} and anything else
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	constraints := schemaConstraints()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, inline := yamlFieldName(f)
+		if inline {
+			// free-form fields
+			s["additionalProperties"] = true
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		fs := schemaOf(f.Type, defs)
+		for k, v := range constraints[name] {
+			fs[k] = v
+		}
+		props[name] = fs
+	}
+	return s
+}
+
+// yamlFieldName returns the YAML key of a struct field and whether it is inlined.
+// It returns an empty name if the field is not serialized.
+func yamlFieldName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" { // unexported
+		return "", false
+	}
+	tag := f.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+	for _, each := range parts[1:] {
+		if each == "inline" {
+			return "", true
+		}
+	}
+	if parts[0] == "-" {
+		return "", false
+	}
+	if parts[0] == "" {
+		return strings.ToLower(f.Name), false
+	}
+	return parts[0], false
+}
+
+// ValidateSchema checks a YAML (or JSON) document against JSONSchema and returns a Finding for each violation.
+func ValidateSchema(content []byte) ([]Finding, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(content, &v); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal YAML:%v", err)
+	}
+	root := JSONSchema()
+	defs := root["$defs"].(map[string]interface{})
+	list := []Finding{}
+	validateValue(root, normalizeKeys(v), "", defs, &list)
+	parsePositions(content, "").locate(list)
+	return list, nil
+}
+
+func validateValue(s map[string]interface{}, v interface{}, path string, defs map[string]interface{}, list *[]Finding) {
+	add := func(format string, args ...interface{}) {
+		*list = append(*list, Finding{Severity: SeverityError, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		def := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		validateValue(def, v, path, defs, list)
+		return
+	}
+	if v == nil {
+		// absent or null values are allowed for all fields
+		return
+	}
+	if types := schemaTypes(s["type"]); len(types) > 0 {
+		got := jsonTypeOf(v)
+		found := false
+		for _, each := range types {
+			// every integer is also a number
+			if each == got || each == "number" && got == "integer" {
+				found = true
+			}
+		}
+		if !found {
+			add("expected %s but got %s", strings.Join(types, " or "), got)
+			return
+		}
+	}
+	switch t := v.(type) {
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, each := range t {
+				validateValue(items, each, joinPath(path, fmt.Sprint(i)), defs, list)
+			}
+		}
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		keys := []string{}
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := props[k]; ok {
+				validateValue(ps.(map[string]interface{}), t[k], joinPath(path, k), defs, list)
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					*list = append(*list, Finding{Severity: SeverityError, Path: joinPath(path, k), Message: "unknown field"})
+				}
+			case map[string]interface{}:
+				validateValue(ap, t[k], joinPath(path, k), defs, list)
+			}
+		}
+	}
+	if enum, ok := s["enum"].([]string); ok {
+		found := false
+		for _, each := range enum {
+			if each == v {
+				found = true
+			}
+		}
+		if !found {
+			add("value [%v] not one of %v", v, enum)
+		}
+	}
+	if min, ok := s["minimum"].(int); ok {
+		if i, ok := toInt(v); ok && i < min {
+			add("value [%v] is less than minimum [%d]", v, min)
+		}
+	}
+	if max, ok := s["maximum"].(int); ok {
+		if i, ok := toInt(v); ok && i > max {
+			add("value [%v] is greater than maximum [%d]", v, max)
+		}
+	}
+}
+
+// schemaTypes returns the names of the "type" keyword, which is either one name or a list of names.
+func schemaTypes(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// jsonTypeOf returns the JSON Schema type name of a decoded value ; no conversion is done
+// such that a quoted "8080" is a string and not an integer.
+func jsonTypeOf(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if t == float64(int64(t)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + extraPathSeparator + key
+}
@@ -0,0 +1,102 @@
+package xconnect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSchemaFileUpToDate(t *testing.T) {
+	d, err := ioutil.ReadFile("xconnect.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(d, JSONSchemaBytes()) {
+		t.Error("xconnect.schema.json is out of date, run: go run ./cmd/xconnect schema > xconnect.schema.json")
+	}
+}
+
+func TestValidateSchemaSpec(t *testing.T) {
+	for each, count := range map[string]int{
+		"spec-xconnect.yaml": 0,
+		// has port -1
+		"xconnect-extended.yaml": 1,
+	} {
+		d, err := ioutil.ReadFile(each)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := ValidateSchema(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(list), count; got != want {
+			t.Errorf("%s: got [%v] want [%v]:%v", each, got, want, list)
+		}
+	}
+}
+
+func TestValidateSchemaViolations(t *testing.T) {
+	cfg := `{
+  "xconnect": {
+    "meta": { "name": 42, "tags": ["a", true] },
+    "listen": { "api": { "port": 0, "protocol": "https" } },
+    "connect": { "db": "not-an-entry" }
+  }
+}`
+	list, err := ValidateSchema([]byte(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"xconnect/connect/db",
+		"xconnect/listen/api/port",
+		"xconnect/listen/api/protocol",
+		"xconnect/meta/name",
+		"xconnect/meta/tags/1",
+	}
+	if got, want := len(list), len(want); got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	for i := range want {
+		if got, want := list[i].Path, want[i]; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+}
+
+func TestValidateSchemaTypes(t *testing.T) {
+	cfg := `
+xconnect:
+  connect:
+    quoted:
+      host: db
+      port: "8080"
+      secure: "true"
+    durations:
+      host: db
+      connect-timeout: 5s
+      request-timeout: 2000
+      idle-timeout: true
+`
+	list, err := ValidateSchema([]byte(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"xconnect/connect/durations/idle-timeout",
+		"xconnect/connect/quoted/port",
+		"xconnect/connect/quoted/secure",
+	}
+	if got, want := len(list), len(want); got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	for i := range want {
+		if got, want := list[i].Path, want[i]; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+	if got, want := list[1].Message, "expected integer but got string"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+package xconnect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// A value of an extra field in a listen or connect entry can refer to a secret, e.g.
+//
+//	password: secret://file/run/secrets/db
+//	password: secret://env/DB_PASSWORD
+//	password: env://DB_PASSWORD
+//
+// The name after secret:// selects the SecretResolver ; the rest is its reference.
+const (
+	secretScheme    = "secret://"
+	envSecretScheme = "env://"
+)
+
+// SecretResolver returns the secret for a reference.
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretResolverFunc is a function that implements SecretResolver.
+type SecretResolverFunc func(ref string) (string, error)
+
+// Resolve is part of SecretResolver
+func (f SecretResolverFunc) Resolve(ref string) (string, error) { return f(ref) }
+
+// FileResolver reads the secret from a file, e.g. secret://file/run/secrets/db reads /run/secrets/db .
+// A trailing newline is removed.
+type FileResolver struct{}
+
+// Resolve is part of SecretResolver
+func (FileResolver) Resolve(ref string) (string, error) {
+	data, err := ioutil.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvResolver reads the secret from an environment variable, e.g. env://DB_PASSWORD .
+type EnvResolver struct{}
+
+// Resolve is part of SecretResolver
+func (EnvResolver) Resolve(ref string) (string, error) {
+	name := strings.TrimPrefix(ref, "/")
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable [%s] is not set", name)
+	}
+	return v, nil
+}
+
+// CommandResolver runs a command and uses its output as the secret, e.g. secret://cmd/pass show db .
+// The command is not run by a shell. A trailing newline is removed.
+// It is not registered by NewLoader ; documents should be trusted before registering it.
+type CommandResolver struct{}
+
+// Resolve is part of SecretResolver
+func (CommandResolver) Resolve(ref string) (string, error) {
+	args := strings.Fields(strings.TrimPrefix(ref, "/"))
+	if len(args) == 0 {
+		return "", errors.New("missing command")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("command [%s] failed:%v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// parseSecretRef returns the resolver name and reference of a secret reference.
+func parseSecretRef(s string) (name, ref string, ok bool) {
+	if strings.HasPrefix(s, envSecretScheme) {
+		return "env", s[len(envSecretScheme):], true
+	}
+	if !strings.HasPrefix(s, secretScheme) {
+		return "", "", false
+	}
+	rest := s[len(secretScheme):]
+	slash := strings.Index(rest, "/")
+	if slash <= 0 {
+		return "", "", false
+	}
+	return rest[:slash], rest[slash:], true
+}
+
+// Secret is the value of a resolved secret reference.
+// It is written as its reference by YAML and JSON encoding and by fmt ; Value returns the secret itself.
+type Secret struct {
+	// Ref is the reference, e.g. env://DB_PASSWORD .
+	Ref   string
+	value string
+}
+
+// Value returns the resolved secret.
+func (s Secret) Value() string { return s.value }
+
+// String returns the reference, never the secret.
+func (s Secret) String() string { return s.Ref }
+
+// GoString returns the reference, never the secret.
+func (s Secret) GoString() string { return fmt.Sprintf("xconnect.Secret{Ref:%q}", s.Ref) }
+
+// MarshalYAML is part of yaml.Marshaler
+func (s Secret) MarshalYAML() (interface{}, error) { return s.Ref, nil }
+
+// MarshalJSON is part of json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) { return json.Marshal(s.Ref) }
+
+// resolveSecrets replaces each secret reference in the extra fields of all listen and connect entries by a Secret.
+// A reference in the url field is an error because the resolved value would be written by YAML and JSON encoding.
+func resolveSecrets(doc *Document, resolvers map[string]SecretResolver) error {
+	x := &doc.XConnect
+	for _, k := range sortedListenKeys(x.Listen) {
+		e := x.Listen[k]
+		if err := rejectSecretURL(e.URL, "xconnect/listen/"+k); err != nil {
+			return err
+		}
+		v, err := resolveSecretsIn(e.ExtraFields, "xconnect/listen/"+k, resolvers)
+		if err != nil {
+			return err
+		}
+		e.ExtraFields, _ = v.(map[string]interface{})
+		x.Listen[k] = e
+	}
+	for _, k := range sortedConnectKeys(x.Connect) {
+		e := x.Connect[k]
+		if err := rejectSecretURL(e.URL, "xconnect/connect/"+k); err != nil {
+			return err
+		}
+		v, err := resolveSecretsIn(e.ExtraFields, "xconnect/connect/"+k, resolvers)
+		if err != nil {
+			return err
+		}
+		e.ExtraFields, _ = v.(map[string]interface{})
+		x.Connect[k] = e
+	}
+	return nil
+}
+
+// rejectSecretURL returns an error if the url is a secret reference ;
+// use ${ENV_VAR} for the url or a reference in an extra field such as password.
+func rejectSecretURL(rawURL, path string) error {
+	if _, _, ok := parseSecretRef(rawURL); ok {
+		return fmt.Errorf("secret reference [%s] at [%s/url] is not supported, use ${ENV_VAR} or a password field", rawURL, path)
+	}
+	return nil
+}
+
+func resolveSecretsIn(v interface{}, path string, resolvers map[string]SecretResolver) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		name, ref, ok := parseSecretRef(t)
+		if !ok {
+			return t, nil
+		}
+		r, ok := resolvers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown secret resolver [%s] at [%s]", name, path)
+		}
+		s, err := r.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve secret [%s] at [%s]:%v", t, path, err)
+		}
+		return Secret{Ref: t, value: s}, nil
+	case map[string]interface{}:
+		for k, each := range t {
+			r, err := resolveSecretsIn(each, path+"/"+escapeKey(k), resolvers)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = r
+		}
+	case map[interface{}]interface{}:
+		for k, each := range t {
+			r, err := resolveSecretsIn(each, path+"/"+escapeKey(toKey(k)), resolvers)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = r
+		}
+	case []interface{}:
+		for i, each := range t {
+			r, err := resolveSecretsIn(each, fmt.Sprintf("%s/%d", path, i), resolvers)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = r
+		}
+	}
+	return v, nil
+}
+
+// revealSecrets returns a copy of v in which each Secret is replaced by its value.
+func revealSecrets(v interface{}) interface{} {
+	switch t := v.(type) {
+	case Secret:
+		return t.value
+	case ListenEntry:
+		t.ExtraFields, _ = revealSecrets(t.ExtraFields).(map[string]interface{})
+		return t
+	case ConnectEntry:
+		t.ExtraFields, _ = revealSecrets(t.ExtraFields).(map[string]interface{})
+		return t
+	case map[string]interface{}:
+		if t == nil {
+			return t
+		}
+		m := make(map[string]interface{}, len(t))
+		for k, each := range t {
+			m[k] = revealSecrets(each)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(t))
+		for k, each := range t {
+			m[k] = revealSecrets(each)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, each := range t {
+			a[i] = revealSecrets(each)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+package xconnect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestLoadSecrets(t *testing.T) {
+	os.Setenv("XCONNECT_TEST_PASSWORD", "env-secret")
+	defer os.Unsetenv("XCONNECT_TEST_PASSWORD")
+	secrets := writeTempFiles(t, map[string]string{
+		"db-password": "file-secret\n",
+	})
+	defer os.RemoveAll(secrets)
+	dir := writeTempFiles(t, map[string]string{
+		"xconnect.yaml": fmt.Sprintf(`
+xconnect:
+  connect:
+    db:
+      url: postgres://app@db/accounts
+      password: secret://file%s
+      replicas:
+        - password: env://XCONNECT_TEST_PASSWORD
+      user: app
+`, filepath.Join(secrets, "db-password")),
+	})
+	defer os.RemoveAll(dir)
+	doc, err := LoadConfig(filepath.Join(dir, "xconnect.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustString("xconnect/connect/db/password"), "file-secret"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustString("xconnect/connect/db/replicas/0/password"), "env-secret"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	var decoded struct {
+		Password string `yaml:"password"`
+	}
+	if err := doc.Decode("xconnect/connect/db", &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decoded.Password, "file-secret"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// never write the secrets
+	y, _ := yaml.Marshal(doc)
+	j, _ := json.Marshal(doc)
+	s := fmt.Sprintf("%v %+v %#v", doc, doc, doc.XConnect.Connect["db"].ExtraFields)
+	for _, each := range []string{string(y), string(j), s} {
+		if strings.Contains(each, "-secret") {
+			t.Errorf("secret found in %s", each)
+		}
+		if !strings.Contains(each, "env://XCONNECT_TEST_PASSWORD") {
+			t.Errorf("reference missing in %s", each)
+		}
+	}
+}
+
+func TestLoadSecretsErrors(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"unknown.yaml": `
+xconnect:
+  connect:
+    db:
+      password: secret://vault/db
+`,
+		"unset.yaml": `
+xconnect:
+  connect:
+    db:
+      password: env://XCONNECT_TEST_UNSET
+`,
+		"url.yaml": `
+xconnect:
+  connect:
+    db:
+      url: env://XCONNECT_TEST_URL
+`,
+	})
+	defer os.RemoveAll(dir)
+	os.Setenv("XCONNECT_TEST_URL", "postgres://app:secret@db/accounts")
+	defer os.Unsetenv("XCONNECT_TEST_URL")
+	for _, each := range []string{"unknown.yaml", "unset.yaml", "url.yaml"} {
+		if _, err := LoadConfig(filepath.Join(dir, each)); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+	l := NewLoader()
+	l.RegisterResolver("vault", SecretResolverFunc(func(ref string) (string, error) {
+		return "vault" + ref, nil
+	}))
+	doc, err := l.Load(filepath.Join(dir, "unknown.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustString("xconnect/connect/db/password"), "vault/db"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	l = NewLoader()
+	l.SkipSecrets = true
+	doc, err = l.Load(filepath.Join(dir, "unset.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.MustString("xconnect/connect/db/password"), "env://XCONNECT_TEST_UNSET"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestCommandResolver(t *testing.T) {
+	name, ref, ok := parseSecretRef("secret://cmd/echo hello")
+	if !ok || name != "cmd" {
+		t.Fatalf("got [%v] [%v]", name, ok)
+	}
+	s, err := CommandResolver{}.Resolve(ref)
+	if err != nil {
+		t.Skip(err)
+	}
+	if got, want := s, "hello"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package xconnect
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrDisabled is returned when using an entry that is marked disabled.
+var ErrDisabled = errors.New("xconnect: entry is disabled")
+
+// shutdownTimeout is the maximum time to wait for active requests when shutting down a server.
+var shutdownTimeout = 10 * time.Second
+
+// Address returns the HOST:PORT to bind ; an empty host means all interfaces.
+// The port is 0 if the entry has no effective port.
+func (e ListenEntry) Address() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.EffectivePort()))
+}
+
+// Listen binds the host and port of this entry ; it is an error if the entry has no effective port.
+// If secure is true then the listener uses TLS as configured by tls-config.
+func (e ListenEntry) Listen(ctx context.Context) (net.Listener, error) {
+	if e.Disabled {
+		return nil, ErrDisabled
+	}
+	if e.EffectivePort() == 0 {
+		return nil, fmt.Errorf("unable to listen, no port for [%s]", e.ResourceID())
+	}
+	var tc *tls.Config
+	if e.EffectiveSecure() {
+		c, err := e.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		tc = c
+	}
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", e.Address())
+	if err != nil {
+		return nil, err
+	}
+	if tc != nil {
+		return tls.NewListener(l, tc), nil
+	}
+	return l, nil
+}
+
+// Serve binds this entry and serves HTTP requests using the handler. It blocks until the server fails.
+func (e ListenEntry) Serve(handler http.Handler) error {
+	l, err := e.Listen(context.Background())
+	if err != nil {
+		return err
+	}
+	return serve(context.Background(), l, handler)
+}
+
+// isHTTP returns true if the protocol or URL scheme of this entry is http or http2
+// and the entry is not a resource of some kind, e.g. a gcp.pubsub topic.
+func (e ListenEntry) isHTTP() bool {
+	if len(e.Kind) != 0 {
+		return false
+	}
+	name := e.Protocol
+	if len(name) == 0 {
+		name = urlScheme(e.URL)
+	}
+	p, ok := LookupProtocol(name)
+	return ok && (p.Name == "http" || p.Name == "http2")
+}
+
+// serve serves HTTP requests until the server fails or the context is cancelled ; then the server is shut down gracefully.
+func serve(ctx context.Context, l net.Listener, handler http.Handler) error {
+	srv := &http.Server{Handler: handler}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(l) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdown); err != nil {
+			return err
+		}
+		if err := <-errc; err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}
+}
+
+// ServeAll binds every enabled HTTP listen entry and serves HTTP requests using the handler for its id.
+// It blocks until the context is cancelled or one of the servers fails ; then all servers are shut down gracefully.
+// Every enabled HTTP listen entry must have a handler ; other entries, e.g. a gcp.pubsub topic, are skipped.
+func (x XConnect) ServeAll(ctx context.Context, handlers map[string]http.Handler) error {
+	ids := []string{}
+	for _, k := range sortedListenKeys(x.Listen) {
+		if e := x.Listen[k]; e.Disabled || !e.isHTTP() {
+			continue
+		}
+		if _, ok := handlers[k]; !ok {
+			return fmt.Errorf("missing handler for listen entry [%s]", k)
+		}
+		ids = append(ids, k)
+	}
+	// bind all before serving any
+	listeners := []net.Listener{}
+	for _, k := range ids {
+		l, err := x.Listen[k].Listen(ctx)
+		if err != nil {
+			for _, each := range listeners {
+				each.Close()
+			}
+			return fmt.Errorf("unable to listen [%s]:%v", k, err)
+		}
+		listeners = append(listeners, l)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i, k := range ids {
+		wg.Add(1)
+		go func(id string, l net.Listener) {
+			defer wg.Done()
+			if err := serve(ctx, l, handlers[id]); err != nil {
+				once.Do(func() { firstErr = fmt.Errorf("listen entry [%s] failed:%v", id, err) })
+			}
+			// stop the others too
+			cancel()
+		}(k, listeners[i])
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,95 @@
+package xconnect
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestListen(t *testing.T) {
+	port := freePort(t)
+	e := ListenEntry{Protocol: "http", Host: "127.0.0.1", Port: &port}
+	l, err := e.Listen(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got, want := l.Addr().(*net.TCPAddr).Port, port; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	e.Disabled = true
+	if _, err := e.Listen(context.Background()); err != ErrDisabled {
+		t.Errorf("got [%v] want [%v]", err, ErrDisabled)
+	}
+	yes := true
+	e = ListenEntry{Host: "127.0.0.1", Port: &port, Secure: &yes}
+	if _, err := e.Listen(context.Background()); err == nil {
+		t.Error("error expected, missing certificate")
+	}
+	e = ListenEntry{Protocol: "tcp", Host: "127.0.0.1"}
+	if _, err := e.Listen(context.Background()); err == nil {
+		t.Error("error expected, missing port")
+	}
+}
+
+func TestServeAll(t *testing.T) {
+	api, web := freePort(t), freePort(t)
+	x := XConnect{Listen: map[string]ListenEntry{
+		"api":   {Protocol: "http", Host: "127.0.0.1", Port: &api},
+		"web":   {Protocol: "http", Host: "127.0.0.1", Port: &web},
+		"admin": {Protocol: "http", Host: "127.0.0.1", Disabled: true},
+		"pull":  {Protocol: "http", Kind: "gcp.pubsub", Resource: "orders"},
+		"db":    {Protocol: "tcp", Host: "127.0.0.1"},
+	}}
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(s)) })
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- x.ServeAll(ctx, map[string]http.Handler{"api": text("api"), "web": text("web")}) }()
+	for id, port := range map[string]int{"api": api, "web": web} {
+		c, _ := ConnectEntry{Protocol: "http", Host: "127.0.0.1", Port: &port}.HTTPClient()
+		var body []byte
+		for i := 0; i < 50; i++ {
+			resp, err := c.Get(ctx, "/")
+			if err == nil {
+				body, _ = ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got, want := string(body), id; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeAll did not stop")
+	}
+}
+
+func TestServeAllMissingHandler(t *testing.T) {
+	port := freePort(t)
+	x := XConnect{Listen: map[string]ListenEntry{"api": {Protocol: "http", Host: "127.0.0.1", Port: &port}}}
+	if err := x.ServeAll(context.Background(), nil); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,340 @@
+// Package sqlconn creates database/sql connections from xconnect connect entries.
+//
+// The driver packages are not imported ; the application must import the one it uses, e.g.
+//
+//	import _ "github.com/lib/pq"
+package sqlconn
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/xconnect"
+)
+
+// Driver names as registered by the driver packages.
+const (
+	// LibPQ is registered by github.com/lib/pq
+	LibPQ = "postgres"
+	// PGX is registered by github.com/jackc/pgx/v4/stdlib
+	PGX = "pgx"
+	// MySQL is registered by github.com/go-sql-driver/mysql
+	MySQL = "mysql"
+	// SQLite is registered by github.com/mattn/go-sqlite3
+	SQLite = "sqlite3"
+)
+
+// Names of extra fields of a connect entry that are used to build a DSN.
+const (
+	driverField   = "driver"
+	databaseField = "database"
+	userField     = "user"
+	passwordField = "password"
+	sslModeField  = "sslmode"
+)
+
+// DriverName returns the database/sql driver name for a connect entry.
+// It uses the extra field "driver" if present, or else the URL scheme, protocol or kind.
+func DriverName(e xconnect.ConnectEntry) (string, error) {
+	if d, err := xconnect.FindString(e, driverField); err == nil && d != "" {
+		return d, nil
+	}
+	candidates := []string{e.Protocol, e.Kind}
+	if cs, err := e.ParseURL(); err == nil {
+		candidates = append([]string{cs.Scheme}, candidates...)
+	} else if i := strings.Index(e.URL, ":"); i != -1 {
+		candidates = append([]string{strings.TrimPrefix(e.URL[:i], "jdbc:")}, candidates...)
+	}
+	for _, each := range candidates {
+		switch strings.ToLower(each) {
+		case "postgres", "postgresql":
+			return LibPQ, nil
+		case "pgx":
+			return PGX, nil
+		case "mysql", "mariadb":
+			return MySQL, nil
+		case "sqlite", "sqlite3", "file":
+			return SQLite, nil
+		}
+	}
+	return "", fmt.Errorf("unable to determine driver for url [%s] protocol [%s] kind [%s]", e.URL, e.Protocol, e.Kind)
+}
+
+// postgresParams maps the URL parameters that lib/pq and pgx accept to their name in the DSN.
+// Other parameters, such as the JDBC-only reWriteBatchedInserts, are rejected by the server and are dropped.
+var postgresParams = map[string]string{
+	"sslmode":                   "sslmode",
+	"sslcert":                   "sslcert",
+	"sslkey":                    "sslkey",
+	"sslrootcert":               "sslrootcert",
+	"connect_timeout":           "connect_timeout",
+	"application_name":          "application_name",
+	"fallback_application_name": "fallback_application_name",
+	"search_path":               "search_path",
+	"options":                   "options",
+	"target_session_attrs":      "target_session_attrs",
+	// JDBC names
+	"ApplicationName":  "application_name",
+	"currentSchema":    "search_path",
+	"connectTimeout":   "connect_timeout",
+	"sslcertfile":      "sslcert",
+	"sslkeyfile":       "sslkey",
+	"sslrootcertfile":  "sslrootcert",
+	"targetServerType": "target_session_attrs",
+}
+
+// postgresParamsOf returns the parameters that are accepted by the postgres drivers.
+func postgresParamsOf(params url.Values) url.Values {
+	accepted := url.Values{}
+	for k, each := range params {
+		if name := postgresParams[k]; name != "" && len(each) > 0 {
+			accepted.Set(name, each[0])
+		}
+	}
+	return accepted
+}
+
+// mysqlParams are the URL parameters that go-sql-driver/mysql accepts.
+// The driver sends any other parameter to the server as a session variable, e.g. SET useSSL=false, which fails.
+var mysqlParams = map[string]bool{
+	"allowAllFiles":            true,
+	"allowCleartextPasswords":  true,
+	"allowFallbackToPlaintext": true,
+	"allowNativePasswords":     true,
+	"allowOldPasswords":        true,
+	"charset":                  true,
+	"checkConnLiveness":        true,
+	"clientFoundRows":          true,
+	"collation":                true,
+	"columnsWithAlias":         true,
+	"connectionAttributes":     true,
+	"interpolateParams":        true,
+	"loc":                      true,
+	"maxAllowedPacket":         true,
+	"multiStatements":          true,
+	"parseTime":                true,
+	"readTimeout":              true,
+	"rejectReadOnly":           true,
+	"serverPubKey":             true,
+	"timeout":                  true,
+	"tls":                      true,
+	"writeTimeout":             true,
+}
+
+// mysqlParamsOf returns the parameters that are accepted by the mysql driver.
+// JDBC names with an equivalent are converted, e.g. connectTimeout=5000 becomes timeout=5000ms ; others are dropped.
+func mysqlParamsOf(params url.Values) url.Values {
+	accepted := url.Values{}
+	for k, each := range params {
+		if len(each) == 0 {
+			continue
+		}
+		v := each[0]
+		switch k {
+		case "connectTimeout":
+			accepted.Set("timeout", v+"ms")
+		case "socketTimeout":
+			accepted.Set("readTimeout", v+"ms")
+			accepted.Set("writeTimeout", v+"ms")
+		case "serverTimezone":
+			accepted.Set("loc", v)
+		case "useSSL":
+			if strings.EqualFold(v, "true") && params.Get("tls") == "" {
+				accepted.Set("tls", "true")
+			}
+		default:
+			if mysqlParams[k] {
+				accepted.Set(k, v)
+			}
+		}
+	}
+	return accepted
+}
+
+// settings are the connection parameters of a connect entry ; extra fields override URL parts.
+type settings struct {
+	host     string
+	port     int
+	database string
+	user     string
+	password string
+	params   url.Values
+}
+
+// settingsOf returns the settings of the entry ; a missing port is set to the default of the protocol.
+func settingsOf(e xconnect.ConnectEntry, protocol string) (settings, error) {
+	s := settings{params: url.Values{}}
+	if len(e.URL) != 0 {
+		cs, err := e.ParseURL()
+		if err != nil {
+			return s, err
+		}
+		s.host, s.port = cs.Host(), cs.Port()
+		s.database, s.user, s.password, s.params = cs.Database, cs.User, cs.Password, cs.Params
+	} else {
+		s.host, s.port = e.Host, e.EffectivePort()
+	}
+	if v, err := xconnect.FindString(e, databaseField); err == nil {
+		s.database = v
+	}
+	if v, err := xconnect.FindString(e, userField); err == nil {
+		s.user = v
+	}
+	if v, err := xconnect.FindString(e, passwordField); err == nil {
+		s.password = v
+	}
+	if v, err := xconnect.FindString(e, sslModeField); err == nil {
+		s.params.Set(sslModeField, v)
+	}
+	if len(s.host) == 0 {
+		return s, fmt.Errorf("missing host for url [%s]", e.URL)
+	}
+	if p, ok := xconnect.LookupProtocol(protocol); ok && s.port == 0 {
+		s.port = p.DefaultPort(e.EffectiveSecure())
+	}
+	return s, nil
+}
+
+// DSN returns the data source name for a connect entry in the format of the driver.
+func DSN(driverName string, e xconnect.ConnectEntry) (string, error) {
+	switch driverName {
+	case LibPQ:
+		return libpqDSN(e)
+	case PGX:
+		return pgxDSN(e)
+	case MySQL:
+		return mysqlDSN(e)
+	case SQLite:
+		return sqliteDSN(e)
+	}
+	return "", fmt.Errorf("unsupported driver [%s]", driverName)
+}
+
+// libpqDSN returns a key=value connection string, e.g. host=db port=5432 dbname=accounts sslmode=disable
+func libpqDSN(e xconnect.ConnectEntry) (string, error) {
+	s, err := settingsOf(e, "postgres")
+	if err != nil {
+		return "", err
+	}
+	s.params = postgresParamsOf(s.params)
+	if e.Secure != nil && *e.Secure && s.params.Get(sslModeField) == "" {
+		s.params.Set(sslModeField, "require")
+	}
+	pairs := []string{"host=" + quoteValue(s.host)}
+	if s.port != 0 {
+		pairs = append(pairs, "port="+strconv.Itoa(s.port))
+	}
+	if s.database != "" {
+		pairs = append(pairs, "dbname="+quoteValue(s.database))
+	}
+	if s.user != "" {
+		pairs = append(pairs, "user="+quoteValue(s.user))
+	}
+	if s.password != "" {
+		pairs = append(pairs, "password="+quoteValue(s.password))
+	}
+	for _, k := range sortedParams(s.params) {
+		pairs = append(pairs, k+"="+quoteValue(s.params.Get(k)))
+	}
+	return strings.Join(pairs, " "), nil
+}
+
+// quoteValue quotes a value of a key=value connection string if needed.
+func quoteValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// pgxDSN returns a postgres URL, e.g. postgres://user:secret@db:5432/accounts?sslmode=disable
+func pgxDSN(e xconnect.ConnectEntry) (string, error) {
+	s, err := settingsOf(e, "postgres")
+	if err != nil {
+		return "", err
+	}
+	s.params = postgresParamsOf(s.params)
+	if e.Secure != nil && *e.Secure && s.params.Get(sslModeField) == "" {
+		s.params.Set(sslModeField, "require")
+	}
+	u := url.URL{Scheme: "postgres", Host: xconnect.HostPort{Host: s.host, Port: s.port}.Address(), Path: "/" + s.database}
+	if s.user != "" {
+		u.User = url.UserPassword(s.user, s.password)
+		if s.password == "" {
+			u.User = url.User(s.user)
+		}
+	}
+	u.RawQuery = s.params.Encode()
+	return u.String(), nil
+}
+
+// mysqlDSN returns a DSN such as user:secret@tcp(db:3306)/shop?parseTime=true
+func mysqlDSN(e xconnect.ConnectEntry) (string, error) {
+	s, err := settingsOf(e, "mysql")
+	if err != nil {
+		return "", err
+	}
+	s.params = mysqlParamsOf(s.params)
+	if e.Secure != nil && *e.Secure && s.params.Get("tls") == "" {
+		s.params.Set("tls", "true")
+	}
+	// the driver does not unescape its DSN ; it reads the user up to the first colon and the password up to the last @
+	// so only a colon in the user cannot be written
+	if strings.Contains(s.user, ":") {
+		return "", fmt.Errorf("user [%s] with a colon cannot be written in a mysql DSN", s.user)
+	}
+	var b strings.Builder
+	if s.user != "" {
+		b.WriteString(s.user)
+		if s.password != "" {
+			b.WriteString(":" + s.password)
+		}
+		b.WriteString("@")
+	}
+	fmt.Fprintf(&b, "tcp(%s)/%s", xconnect.HostPort{Host: s.host, Port: s.port}.Address(), s.database)
+	if len(s.params) > 0 {
+		b.WriteString("?" + s.params.Encode())
+	}
+	return b.String(), nil
+}
+
+// sqliteDSN returns a file DSN such as file:/data/app.db?cache=shared
+// The file is the extra field "database" or the path of the URL, e.g. sqlite:///data/app.db .
+func sqliteDSN(e xconnect.ConnectEntry) (string, error) {
+	file, query := "", ""
+	if len(e.URL) != 0 {
+		rest := e.URL
+		for _, prefix := range []string{"sqlite3://", "sqlite://", "file://", "file:"} {
+			if strings.HasPrefix(rest, prefix) {
+				rest = rest[len(prefix):]
+				break
+			}
+		}
+		if q := strings.Index(rest, "?"); q != -1 {
+			rest, query = rest[:q], rest[q+1:]
+		}
+		file = rest
+	}
+	if v, err := xconnect.FindString(e, databaseField); err == nil {
+		file = v
+	}
+	if file == "" {
+		return "", fmt.Errorf("missing database file for url [%s]", e.URL)
+	}
+	dsn := "file:" + file
+	if query != "" {
+		dsn += "?" + query
+	}
+	return dsn, nil
+}
+
+func sortedParams(params url.Values) (keys []string) {
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
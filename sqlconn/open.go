@@ -0,0 +1,53 @@
+package sqlconn
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/emicklei/xconnect"
+)
+
+// Names of extra fields of a connect entry with the pool settings of a *sql.DB.
+const (
+	maxIdleConnsField    = "max-idle-conns"
+	connMaxLifetimeField = "conn-max-lifetime"
+)
+
+func init() {
+	xconnect.RegisterExtensionKeys(driverField, databaseField, userField, passwordField, sslModeField, maxIdleConnsField, connMaxLifetimeField)
+}
+
+// Open returns a *sql.DB for the connect entry at a slash path, e.g. xconnect/connect/some-db .
+// The pool size is max-connections, or the extra field max-open-conns ; other pool settings are read from the extra fields max-idle-conns and conn-max-lifetime.
+// The driver package must be imported by the application.
+func Open(doc xconnect.Document, path string) (*sql.DB, error) {
+	var e xconnect.ConnectEntry
+	if err := doc.Decode(path, &e); err != nil {
+		return nil, err
+	}
+	return OpenEntry(e)
+}
+
+// OpenEntry returns a *sql.DB for a connect entry. See Open.
+func OpenEntry(e xconnect.ConnectEntry) (*sql.DB, error) {
+	driverName, err := DriverName(e)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := DSN(driverName, e)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open [%s] database:%v", driverName, err)
+	}
+	db.SetMaxOpenConns(e.Tuning().MaxConnections)
+	if n, err := xconnect.FindInt(e, maxIdleConnsField); err == nil {
+		db.SetMaxIdleConns(n)
+	}
+	if d, err := xconnect.FindDuration(e, connMaxLifetimeField); err == nil {
+		db.SetConnMaxLifetime(d)
+	}
+	return db, nil
+}
@@ -0,0 +1,83 @@
+package sqlconn
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/emicklei/xconnect"
+)
+
+func TestDSN(t *testing.T) {
+	doc, err := xconnect.LoadConfig("testdata/sqlconn.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []struct {
+		id     string
+		driver string
+		dsn    string
+	}{
+		{"pq-db", LibPQ, `host=localhost port=5432 dbname=postgres user=app password='it\'s secret' application_name=shop sslmode=disable`},
+		{"pgx-db", PGX, "postgres://app@pg.internal:5432/accounts?sslmode=require"},
+		{"mysql-db", MySQL, "root:p@ss/w:rd@tcp(shop-db:3306)/shop?parseTime=true"},
+		{"mysql-jdbc-db", MySQL, "tcp(shop-db:3306)/shop?loc=UTC&parseTime=true&timeout=5000ms"},
+		{"sqlite-db", SQLite, "file:/data/app.db?cache=shared"},
+	} {
+		e := doc.XConnect.Connect[each.id]
+		name, err := DriverName(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := name, each.driver; got != want {
+			t.Errorf("%s: got [%v] want [%v]", each.id, got, want)
+		}
+		dsn, err := DSN(name, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := dsn, each.dsn; got != want {
+			t.Errorf("%s: got [%v] want [%v]", each.id, got, want)
+		}
+	}
+	if _, err := DriverName(doc.XConnect.Connect["unknown-db"]); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := DSN("oracle", doc.XConnect.Connect["pq-db"]); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := DSN(MySQL, doc.XConnect.Connect["mysql-colon-db"]); err == nil {
+		t.Error("error expected")
+	}
+}
+
+// fakeDriver is registered as the lib/pq driver ; it never connects.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return nil, errors.New("not connected") }
+
+func init() {
+	sql.Register(LibPQ, fakeDriver{})
+}
+
+func TestOpen(t *testing.T) {
+	doc, err := xconnect.LoadConfig("testdata/sqlconn.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(doc, "xconnect/connect/pq-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got, want := db.Stats().MaxOpenConnections, 7; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, err := Open(doc, "xconnect/connect/missing-db"); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := Open(doc, "xconnect/connect/pgx-db"); err == nil {
+		t.Error("error expected, pgx driver not registered")
+	}
+}
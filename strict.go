@@ -0,0 +1,203 @@
+package xconnect
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// legacyNames maps names that are used instead of a field of listen and connect entries to that field.
+var legacyNames = map[string]string{
+	"tls":            "secure",
+	"ssl":            "secure",
+	"hostname":       "host",
+	"address":        "url",
+	"prot":           "protocol",
+	"proto":          "protocol",
+	"scheme":         "protocol",
+	"retries":        "retry",
+	"timeout":        "request-timeout",
+	"max-open-conns": "max-connections",
+	"enabled":        "disabled",
+}
+
+var (
+	extensionKeysMutex sync.RWMutex
+	// extensionKeys are extra fields that are intended, even if they look like a known field.
+	extensionKeys = map[string]bool{}
+)
+
+func init() {
+	RegisterExtensionKeys(keepAliveField, "ui-fillcolor")
+}
+
+// RegisterExtensionKeys adds names of extra fields of listen and connect entries that are intended,
+// such that CheckExtraFields does not report them. Applications can use this for their own fields.
+func RegisterExtensionKeys(names ...string) {
+	extensionKeysMutex.Lock()
+	defer extensionKeysMutex.Unlock()
+	for _, each := range names {
+		extensionKeys[each] = true
+	}
+}
+
+func isExtensionKey(name string) bool {
+	extensionKeysMutex.RLock()
+	defer extensionKeysMutex.RUnlock()
+	return extensionKeys[name]
+}
+
+// FieldsError is returned by a strict Loader and has a finding for each suspicious extra field.
+type FieldsError struct {
+	Findings []Finding
+}
+
+func (e *FieldsError) Error() string {
+	list := make([]string, len(e.Findings))
+	for i, each := range e.Findings {
+		list[i] = each.String()
+	}
+	return "unknown fields: " + strings.Join(list, "; ")
+}
+
+// CheckExtraFields returns a finding for each extra field of a listen or connect entry
+// that looks like a misspelling or a legacy name of a known field.
+// A legacy name (tls instead of secure), a different spelling (connect_timeout) or swapped letters (hots instead of host)
+// is an error ; a name that is only close to a known field (hosts, port2) is a warning.
+// Registered extension keys are not reported.
+func (x XConnect) CheckExtraFields() (list []Finding) {
+	defer func() { x.positions.locate(list) }()
+	listenNames, connectNames := knownFieldNames(ListenEntry{}), knownFieldNames(ConnectEntry{})
+	for _, k := range sortedListenKeys(x.Listen) {
+		list = append(list, checkExtraFields(x.Listen[k].ExtraFields, listenNames, "xconnect/listen/"+escapeKey(k))...)
+	}
+	for _, k := range sortedConnectKeys(x.Connect) {
+		list = append(list, checkExtraFields(x.Connect[k].ExtraFields, connectNames, "xconnect/connect/"+escapeKey(k))...)
+	}
+	return
+}
+
+func checkExtraFields(extra map[string]interface{}, known []string, path string) (list []Finding) {
+	keys := []string{}
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if isExtensionKey(k) {
+			continue
+		}
+		if name, severity, ok := suggestField(k, known); ok {
+			list = append(list, Finding{Severity: severity, Path: joinPath(path, escapeKey(k)),
+				Message: fmt.Sprintf("unknown field [%s], did you mean [%s]?", k, name)})
+		}
+	}
+	return
+}
+
+// suggestField returns the known field for a legacy name or for a name that is close to it.
+// The severity is an error if the key can only mean that field.
+func suggestField(key string, known []string) (string, Severity, bool) {
+	key = strings.ToLower(key)
+	contains := func(name string) bool {
+		for _, each := range known {
+			if each == name {
+				return true
+			}
+		}
+		return false
+	}
+	if name, ok := legacyNames[key]; ok && contains(name) {
+		return name, SeverityError, true
+	}
+	normalize := strings.NewReplacer("-", "", "_", "", ".", "").Replace
+	for _, each := range known {
+		// e.g. connectTimeout, connect_timeout
+		if normalize(key) == normalize(each) {
+			return each, SeverityError, true
+		}
+		// e.g. hots, protcool
+		if isTransposition(key, each) {
+			return each, SeverityError, true
+		}
+	}
+	for _, each := range known {
+		// e.g. prtocol, hosts
+		max := 2
+		if len(each) <= 5 {
+			max = 1
+		}
+		if editDistance(key, each) <= max {
+			return each, SeverityWarning, true
+		}
+		// e.g. req-timeout
+		if len(key) >= 4 && strings.HasPrefix(each, key) {
+			return each, SeverityWarning, true
+		}
+	}
+	return "", SeverityWarning, false
+}
+
+// isTransposition returns true if b is a with two adjacent characters swapped.
+func isTransposition(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) != len(rb) {
+		return false
+	}
+	for i := 0; i < len(ra)-1; i++ {
+		if ra[i] != rb[i] {
+			return ra[i] == rb[i+1] && ra[i+1] == rb[i] && string(ra[i+2:]) == string(rb[i+2:])
+		}
+	}
+	return false
+}
+
+// knownFieldNames returns the YAML keys of the fields of an entry.
+func knownFieldNames(entry interface{}) (names []string) {
+	rt := reflect.TypeOf(entry)
+	for i := 0; i < rt.NumField(); i++ {
+		if name, _ := yamlFieldName(rt.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// editDistance returns the number of inserts, deletes, substitutions and transpositions of adjacent characters
+// to change a into b (optimal string alignment distance).
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(first int, others ...int) int {
+	m := first
+	for _, each := range others {
+		if each < m {
+			m = each
+		}
+	}
+	return m
+}
@@ -0,0 +1,131 @@
+package xconnect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const strictYAML = `xconnect:
+  meta:
+    name: strict
+  listen:
+    api:
+      hostname: localhost
+      prot: http
+      port: 8080
+      ui-fillcolor: red
+  connect:
+    db:
+      url: postgres://db:5432/shop
+      conectTimeout: 2s
+      retries: 3
+      hots: db
+      pool: 5
+      keep-alive: 30s
+`
+
+func TestCheckExtraFields(t *testing.T) {
+	var doc Document
+	if err := yaml.Unmarshal([]byte(strictYAML), &doc); err != nil {
+		t.Fatal(err)
+	}
+	list := doc.XConnect.CheckExtraFields()
+	want := []struct {
+		path, message string
+		severity      Severity
+	}{
+		{"xconnect/listen/api/hostname", "unknown field [hostname], did you mean [host]?", SeverityError},
+		{"xconnect/listen/api/prot", "unknown field [prot], did you mean [protocol]?", SeverityError},
+		{"xconnect/connect/db/conectTimeout", "unknown field [conectTimeout], did you mean [connect-timeout]?", SeverityWarning},
+		{"xconnect/connect/db/hots", "unknown field [hots], did you mean [host]?", SeverityError},
+		{"xconnect/connect/db/retries", "unknown field [retries], did you mean [retry]?", SeverityError},
+	}
+	if got, want := len(list), len(want); got != want {
+		t.Fatalf("got [%v] want [%v]: %v", got, want, list)
+	}
+	for i, each := range list {
+		if got, want := each.Path, want[i].path; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+		if got, want := each.Message, want[i].message; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+		if got, want := each.Severity, want[i].severity; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+	}
+	RegisterExtensionKeys("hots")
+	defer func() {
+		extensionKeysMutex.Lock()
+		delete(extensionKeys, "hots")
+		extensionKeysMutex.Unlock()
+	}()
+	if got, want := len(doc.XConnect.CheckExtraFields()), 4; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestSuggestField(t *testing.T) {
+	known := knownFieldNames(ConnectEntry{})
+	for _, each := range []struct {
+		key, field string
+		severity   Severity
+	}{
+		{"tls", "secure", SeverityError},
+		{"Hostname", "host", SeverityError},
+		{"scheme", "protocol", SeverityError},
+		{"max_connections", "max-connections", SeverityError},
+		{"max-open-conns", "max-connections", SeverityError},
+		{"tls_config", "tls-config", SeverityError},
+		{"hots", "host", SeverityError},
+		{"protcool", "protocol", SeverityError},
+		{"protocl", "protocol", SeverityWarning},
+		{"disable", "disabled", SeverityWarning},
+		{"port2", "port", SeverityWarning},
+		{"hosts", "host", SeverityWarning},
+		{"resources", "resource", SeverityWarning},
+		{"secured", "secure", SeverityWarning},
+		{"pool", "", SeverityWarning},
+		{"topics", "", SeverityWarning},
+		{"password", "", SeverityWarning},
+	} {
+		got, severity, _ := suggestField(each.key, known)
+		if want := each.field; got != want {
+			t.Errorf("%s: got [%v] want [%v]", each.key, got, want)
+		}
+		if want := each.severity; severity != want {
+			t.Errorf("%s: got [%v] want [%v]", each.key, severity, want)
+		}
+	}
+	// retry is not a field of a listen entry
+	if got, _, ok := suggestField("retries", knownFieldNames(ListenEntry{})); ok {
+		t.Errorf("got [%v] want none", got)
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"xconnect.yaml": strictYAML})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "xconnect.yaml")
+	if _, err := LoadConfig(file); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLoader()
+	l.Strict = true
+	_, err := l.Load(file)
+	var ferr *FieldsError
+	if !errors.As(err, &ferr) {
+		t.Fatalf("got [%v] want *FieldsError", err)
+	}
+	// conectTimeout is only close to connect-timeout and is a warning
+	if got, want := len(ferr.Findings), 4; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := ferr.Findings[0].Position, (Position{File: file, Line: 6, Column: 7}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
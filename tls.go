@@ -0,0 +1,217 @@
+package xconnect
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// TLS describes the TLS material of a listen or connect entry.
+// File paths are local to the deployment of the service.
+type TLS struct {
+	// CAFile is a PEM bundle to verify the other party ; clients for a listen entry, the server for a connect entry.
+	CAFile string `yaml:"ca-file,omitempty" json:"ca-file,omitempty"`
+	// CertFile and KeyFile are the certificate of the server for a listen entry, or of the client for a connect entry.
+	CertFile string `yaml:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile  string `yaml:"key-file,omitempty" json:"key-file,omitempty"`
+	// ServerName overrides the host to verify the certificate of the server.
+	ServerName string `yaml:"server-name,omitempty" json:"server-name,omitempty"`
+	// MinVersion is one of 1.0, 1.1, 1.2 or 1.3 .
+	MinVersion string `yaml:"min-version,omitempty" json:"min-version,omitempty"`
+	// Mutual is true if clients must present a certificate.
+	Mutual bool `yaml:"mutual,omitempty" json:"mutual,omitempty"`
+}
+
+// tlsVersions maps MinVersion values to crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (t TLS) find(keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return t, true
+	}
+	switch keys[0] {
+	case "ca-file":
+		return t.CAFile, true
+	case "cert-file":
+		return t.CertFile, true
+	case "key-file":
+		return t.KeyFile, true
+	case "server-name":
+		return t.ServerName, true
+	case "min-version":
+		return t.MinVersion, true
+	case "mutual":
+		return t.Mutual, true
+	}
+	return nil, false
+}
+
+// hasCertificate returns true if both the certificate and key file are set.
+func (t *TLS) hasCertificate() bool {
+	return t != nil && t.CertFile != "" && t.KeyFile != ""
+}
+
+// isMutual returns true if mutual TLS is required.
+func (t *TLS) isMutual() bool {
+	return t != nil && t.Mutual
+}
+
+// TLSConfig returns the server configuration for this entry.
+// A certificate is required ; if mutual is true then clients must present a certificate signed by the CA bundle.
+func (e ListenEntry) TLSConfig() (*tls.Config, error) {
+	if !e.TLS.hasCertificate() {
+		return nil, errors.New("secure listen entry requires tls-config with cert-file and key-file")
+	}
+	c, err := e.TLS.config()
+	if err != nil {
+		return nil, err
+	}
+	c.ClientCAs, c.RootCAs = c.RootCAs, nil
+	if e.TLS.Mutual {
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return c, nil
+}
+
+// TLSConfig returns the client configuration for this entry.
+// The server name is the one from tls-config or else the host of the entry, as configured.
+func (e ConnectEntry) TLSConfig() (*tls.Config, error) {
+	if e.TLS.isMutual() && !e.TLS.hasCertificate() {
+		return nil, errors.New("mutual TLS requires tls-config with cert-file and key-file")
+	}
+	c := new(tls.Config)
+	if e.TLS != nil {
+		tc, err := e.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		c = tc
+	}
+	if c.ServerName == "" {
+		c.ServerName = e.serverName()
+	}
+	return c, nil
+}
+
+// serverName returns the configured host, or the first host of the URL ;
+// unlike the Endpoint host it is not lowercased nor expanded as a Kubernetes service name.
+func (e ConnectEntry) serverName() string {
+	if len(e.URL) != 0 {
+		c, err := e.ParseURL()
+		if err != nil {
+			return ""
+		}
+		return c.Host()
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(e.Host, "["), "]")
+}
+
+// config returns the configuration with the CA bundle as RootCAs.
+func (t TLS) config() (*tls.Config, error) {
+	c := &tls.Config{ServerName: t.ServerName}
+	if t.MinVersion != "" {
+		v, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid min-version [%s]", t.MinVersion)
+		}
+		c.MinVersion = v
+	}
+	if t.CAFile != "" {
+		pem, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca-file:%v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca-file [%s]", t.CAFile)
+		}
+		c.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load certificate:%v", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+// validateTLS checks that the TLS material is complete for how it is used.
+func validateTLS(t *TLS, secure bool, listen bool, path string, add addFinding) {
+	if t == nil {
+		if secure && listen {
+			add(SeverityError, path+"/tls-config", "missing tls-config with cert-file and key-file")
+		}
+		return
+	}
+	path += "/tls-config"
+	if !secure {
+		add(SeverityWarning, path, "tls-config is ignored because secure is not true")
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		add(SeverityError, path, "cert-file and key-file must be set together")
+	}
+	if t.MinVersion != "" {
+		if _, ok := tlsVersions[t.MinVersion]; !ok {
+			add(SeverityError, path+"/min-version", "invalid min-version [%s], expected one of [1.0 1.1 1.2 1.3]", t.MinVersion)
+		}
+	}
+	if listen {
+		if t.CertFile == "" && t.KeyFile == "" {
+			add(SeverityError, path, "missing cert-file and key-file")
+		}
+		if t.Mutual && t.CAFile == "" {
+			add(SeverityError, path, "mutual TLS requires a ca-file to verify clients")
+		}
+		if t.ServerName != "" {
+			add(SeverityWarning, path+"/server-name", "server-name is only used by connect entries")
+		}
+		return
+	}
+	if t.Mutual && t.CertFile == "" && t.KeyFile == "" {
+		add(SeverityError, path, "mutual TLS requires cert-file and key-file")
+	}
+}
+
+// ValidateConnections checks the connect entries of each section against the matching listen entries
+// of all sections and returns all findings. Entries match by their Endpoint.
+func ValidateConnections(sections ...XConnect) (list []Finding) {
+	for _, from := range sections {
+		start := len(list)
+		for _, k := range sortedConnectKeys(from.Connect) {
+			c := from.Connect[k]
+			if c.Disabled {
+				continue
+			}
+			path := fmt.Sprintf("xconnect/connect/%s/tls-config", k)
+			for _, to := range sections {
+				for _, lk := range sortedListenKeys(to.Listen) {
+					l := to.Listen[lk]
+					if l.Disabled || !c.Endpoint().Matches(l.Endpoint()) {
+						continue
+					}
+					target := fmt.Sprintf("listen entry [%s] of [%s]", lk, to.Meta.Name)
+					if l.TLS.isMutual() && !c.TLS.hasCertificate() {
+						list = append(list, Finding{Severity: SeverityError, Path: path,
+							Message: fmt.Sprintf("[%s] connects to %s that requires mutual TLS, but has no client certificate", from.Meta.Name, target)})
+					}
+					if c.TLS.isMutual() && !l.TLS.isMutual() {
+						list = append(list, Finding{Severity: SeverityWarning, Path: path,
+							Message: fmt.Sprintf("[%s] expects mutual TLS, but %s does not require it", from.Meta.Name, target)})
+					}
+				}
+			}
+		}
+		from.positions.locate(list[start:])
+	}
+	return
+}
@@ -0,0 +1,223 @@
+package xconnect
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 that is also its own CA.
+// The caller must remove the directory.
+func writeTestCertificate(t *testing.T) (dir, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "xconnect test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err = ioutil.TempDir("", "xconnect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, certFile, keyFile := writeTestCertificate(t)
+	defer os.RemoveAll(dir)
+	l := ListenEntry{TLS: &TLS{CertFile: certFile, KeyFile: keyFile, CAFile: certFile, Mutual: true, MinVersion: "1.2"}}
+	c, err := l.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.ClientAuth, tls.RequireAndVerifyClientCert; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := c.MinVersion, uint16(tls.VersionTLS12); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if c.ClientCAs == nil || c.RootCAs != nil {
+		t.Error("CA bundle expected for clients only")
+	}
+	e := ConnectEntry{Host: "db.example.com"}
+	c, err = e.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.ServerName, "db.example.com"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// as configured, not lowercased nor expanded
+	for _, each := range []ConnectEntry{{Host: "DB.ns"}, {URL: "postgres://DB.ns:5432/shop"}} {
+		c, err = each.TLSConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := c.ServerName, "DB.ns"; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+	}
+	e.TLS = &TLS{ServerName: "db.internal", Mutual: true}
+	if _, err := e.TLSConfig(); err == nil {
+		t.Error("error expected, missing client certificate")
+	}
+	if _, err := (ListenEntry{}).TLSConfig(); err == nil {
+		t.Error("error expected, missing certificate")
+	}
+	if _, err := (ConnectEntry{TLS: &TLS{MinVersion: "1.4"}}).TLSConfig(); err == nil {
+		t.Error("error expected, invalid min-version")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, certFile, keyFile := writeTestCertificate(t)
+	defer os.RemoveAll(dir)
+	yes := true
+	port := freePort(t)
+	l := ListenEntry{Protocol: "http", Host: "127.0.0.1", Port: &port, Secure: &yes,
+		TLS: &TLS{CertFile: certFile, KeyFile: keyFile, CAFile: certFile, Mutual: true}}
+	x := XConnect{Listen: map[string]ListenEntry{"api": l}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go x.ServeAll(ctx, map[string]http.Handler{"api": http.NotFoundHandler()})
+
+	e := ConnectEntry{Protocol: "http", Host: "127.0.0.1", Port: &port, Secure: &yes,
+		TLS: &TLS{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}}
+	client, err := e.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get(ctx, "/"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	e.TLS = &TLS{CAFile: certFile}
+	client, _ = e.HTTPClient()
+	if resp, err = client.Get(ctx, "/"); err == nil {
+		resp.Body.Close()
+		t.Error("error expected, no client certificate")
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	cfg := `
+xconnect:
+  meta:
+    name: test
+  listen:
+    api:
+      host: localhost
+      port: 8443
+      secure: true
+      tls-config:
+        cert-file: tls.crt
+        mutual: true
+    web:
+      url: https://localhost:8443
+  connect:
+    db:
+      host: db
+      tls-config:
+        min-version: "1.4"
+`
+	var doc Document
+	if err := yaml.Unmarshal([]byte(cfg), &doc); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{}
+	for _, each := range doc.Validate() {
+		paths = append(paths, each.Path)
+	}
+	want := []string{
+		"xconnect/listen/api/tls-config", // key-file
+		"xconnect/listen/api/tls-config", // ca-file
+		"xconnect/listen/web/tls-config", // missing
+		"xconnect/connect/db/tls-config", // not secure
+		"xconnect/connect/db/tls-config/min-version",
+	}
+	if got, want := len(paths), len(want); got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, doc.Validate())
+	}
+	for i := range want {
+		if got, want := paths[i], want[i]; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+	if got, want := FindBool(doc, "xconnect/listen/api/tls-config/mutual"); got != true || want != nil {
+		t.Errorf("got [%v] want [true]:%v", got, want)
+	}
+}
+
+func TestValidateConnections(t *testing.T) {
+	port := 8443
+	server := XConnect{Meta: MetaProperties{Name: "server"}, Listen: map[string]ListenEntry{
+		"api": {Host: "api.ns.svc.cluster.local", Port: &port, TLS: &TLS{Mutual: true}},
+	}}
+	client := XConnect{Meta: MetaProperties{Name: "client"}, Connect: map[string]ConnectEntry{
+		"api":   {Host: "api.ns", Port: &port},
+		"other": {Host: "other", Port: &port, TLS: &TLS{Mutual: true}},
+	}}
+	list := ValidateConnections(server, client)
+	if got, want := len(list), 1; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	if got, want := list[0].Path, "xconnect/connect/api/tls-config"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	server.Listen["api"].TLS.Mutual = false
+	e := client.Connect["api"]
+	e.TLS = &TLS{Mutual: true, CertFile: "tls.crt", KeyFile: "tls.key"}
+	client.Connect["api"] = e
+	list = ValidateConnections(server, client)
+	if got, want := len(list), 1; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	if got, want := list[0].Severity, SeverityWarning; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
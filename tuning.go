@@ -0,0 +1,186 @@
+package xconnect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is written as a string such as 5s or 1m30s.
+// When read, an integer is a number of milliseconds.
+type Duration time.Duration
+
+// String returns the duration as formatted by time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// UnmarshalYAML is part of yaml.Unmarshaler
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	t, ok := toDuration(v)
+	if !ok {
+		return fmt.Errorf("invalid duration [%v]", v)
+	}
+	*d = Duration(t)
+	return nil
+}
+
+// MarshalYAML is part of yaml.Marshaler
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON is part of json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON is part of json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	t, ok := toDuration(fromJSON(v))
+	if !ok {
+		return fmt.Errorf("invalid duration [%s]", data)
+	}
+	*d = Duration(t)
+	return nil
+}
+
+// findDuration returns the time.Duration of an optional field.
+func findDuration(d *Duration) (interface{}, bool) {
+	if d == nil {
+		return nil, false
+	}
+	return time.Duration(*d), true
+}
+
+// RetryPolicy tells how often a failed connection or request is tried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first one.
+	Attempts int `yaml:"attempts,omitempty" json:"attempts,omitempty"`
+	// Backoff is the wait time before the first retry ; it doubles for each next retry.
+	Backoff Duration `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+}
+
+func (r RetryPolicy) find(keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return r, true
+	}
+	switch keys[0] {
+	case "attempts":
+		return r.Attempts, true
+	case "backoff":
+		return time.Duration(r.Backoff), true
+	}
+	return nil, false
+}
+
+// Tuning holds the effective tuning values of a connect entry. A zero value means no limit.
+type Tuning struct {
+	ConnectTimeout time.Duration
+	RequestTimeout time.Duration
+	// RetryAttempts is the maximum number of attempts, including the first one.
+	RetryAttempts  int
+	RetryBackoff   time.Duration
+	MaxConnections int
+	IdleTimeout    time.Duration
+}
+
+// retries returns the number of attempts after the first one.
+func (t Tuning) retries() int {
+	if t.RetryAttempts > 1 {
+		return t.RetryAttempts - 1
+	}
+	return 0
+}
+
+// defaultRetryBackoff is used if the retry policy has no backoff.
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// Names of extra fields of a connect entry that were used before the tuning fields ; these are still read.
+const (
+	// retriesField is the number of retries after the first attempt.
+	retriesField      = "retries"
+	maxOpenConnsField = "max-open-conns"
+)
+
+// Tuning returns the tuning values of this entry ; values that are not set come from the defaults of the protocol.
+// The extra fields retries and max-open-conns are used if retry and max-connections are not set.
+func (e ConnectEntry) Tuning() Tuning {
+	t := e.protocol().Tuning
+	if e.ConnectTimeout != nil {
+		t.ConnectTimeout = time.Duration(*e.ConnectTimeout)
+	}
+	if e.RequestTimeout != nil {
+		t.RequestTimeout = time.Duration(*e.RequestTimeout)
+	}
+	if e.Retry != nil && e.Retry.Attempts != 0 {
+		t.RetryAttempts = e.Retry.Attempts
+	} else if n, ok := toInt(e.ExtraFields[retriesField]); ok && n > 0 {
+		t.RetryAttempts = n + 1
+	}
+	if e.Retry != nil && e.Retry.Backoff != 0 {
+		t.RetryBackoff = time.Duration(e.Retry.Backoff)
+	}
+	if t.RetryBackoff == 0 {
+		t.RetryBackoff = defaultRetryBackoff
+	}
+	if e.MaxConnections != nil {
+		t.MaxConnections = *e.MaxConnections
+	} else if n, ok := toInt(e.ExtraFields[maxOpenConnsField]); ok {
+		t.MaxConnections = n
+	}
+	if e.IdleTimeout != nil {
+		t.IdleTimeout = time.Duration(*e.IdleTimeout)
+	}
+	return t
+}
+
+// HasTimeout returns true if the document sets a connect or request timeout for this entry.
+// Defaults of the protocol are not taken into account.
+func (e ConnectEntry) HasTimeout() bool {
+	return e.ConnectTimeout != nil || e.RequestTimeout != nil
+}
+
+// validateTuning detects negative tuning values.
+func validateTuning(e ConnectEntry, path string, add addFinding) {
+	for _, each := range []struct {
+		name  string
+		value *Duration
+	}{
+		{"connect-timeout", e.ConnectTimeout},
+		{"request-timeout", e.RequestTimeout},
+		{"idle-timeout", e.IdleTimeout},
+	} {
+		if each.value != nil && *each.value < 0 {
+			add(SeverityError, path+"/"+each.name, "negative duration [%s]", each.value)
+		}
+	}
+	if e.Retry != nil && (e.Retry.Attempts < 0 || e.Retry.Backoff < 0) {
+		add(SeverityError, path+"/retry", "negative attempts or backoff")
+	}
+	if e.MaxConnections != nil && *e.MaxConnections < 0 {
+		add(SeverityError, path+"/max-connections", "negative value [%d]", *e.MaxConnections)
+	}
+}
+
+// protocol returns the registered protocol of the URL scheme or of the protocol field.
+func (e ConnectEntry) protocol() Protocol {
+	if s := urlScheme(e.URL); s != "" {
+		if p, ok := LookupProtocol(s); ok {
+			return p
+		}
+	}
+	p, _ := LookupProtocol(e.Protocol)
+	return p
+}
@@ -0,0 +1,127 @@
+package xconnect
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const tuningYAML = `
+xconnect:
+  connect:
+    api:
+      protocol: http
+      host: api
+      connect-timeout: 2s
+      request-timeout: 1500
+      retry:
+        attempts: 3
+        backoff: 50ms
+      max-connections: 10
+      idle-timeout: 1m
+    cache:
+      url: redis://cache:6379
+    socket:
+      protocol: tcp
+      host: somewhere
+`
+
+func TestTuning(t *testing.T) {
+	var doc Document
+	if err := yaml.Unmarshal([]byte(tuningYAML), &doc); err != nil {
+		t.Fatal(err)
+	}
+	api := doc.XConnect.Connect["api"].Tuning()
+	if got, want := api, (Tuning{
+		ConnectTimeout: 2 * time.Second,
+		RequestTimeout: 1500 * time.Millisecond,
+		RetryAttempts:  3,
+		RetryBackoff:   50 * time.Millisecond,
+		MaxConnections: 10,
+		IdleTimeout:    time.Minute,
+	}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := api.retries(), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// defaults of the protocol of the URL scheme
+	cache := doc.XConnect.Connect["cache"].Tuning()
+	if got, want := cache.ConnectTimeout, time.Second; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.XConnect.Connect["cache"].HasTimeout(), false; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	socket := doc.XConnect.Connect["socket"].Tuning()
+	if got, want := socket, (Tuning{RetryBackoff: defaultRetryBackoff}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustDuration("xconnect/connect/api/retry/backoff"), 50*time.Millisecond; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := doc.MustInt("xconnect/connect/api/max-connections"), 10; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestTuningLegacyFields(t *testing.T) {
+	e := ConnectEntry{Protocol: "http", Host: "api", ExtraFields: map[string]interface{}{
+		"retries":        2,
+		"max-open-conns": 4,
+	}}
+	tuning := e.Tuning()
+	if got, want := tuning.RetryAttempts, 3; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := tuning.MaxConnections, 4; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// the tuning field wins
+	e.Retry = &RetryPolicy{Attempts: 1}
+	if got, want := e.Tuning().RetryAttempts, 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// no request timeout unless set
+	c, err := ConnectEntry{Protocol: "http", Host: "api"}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Timeout, time.Duration(0); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestTuningJSON(t *testing.T) {
+	var doc Document
+	if err := yaml.Unmarshal([]byte(tuningYAML), &doc); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(doc.XConnect.Connect["api"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e ConnectEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Tuning(), doc.XConnect.Connect["api"].Tuning(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := e.RequestTimeout.String(), "1.5s"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if err := json.Unmarshal([]byte(`{"connect-timeout":true}`), &e); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestValidateTuning(t *testing.T) {
+	timeout, max := Duration(-time.Second), -1
+	e := ConnectEntry{Host: "api", ConnectTimeout: &timeout, MaxConnections: &max}
+	if got, want := len(validateConnect(e)), 2; got != want {
+		t.Errorf("got [%v] want [%v]:%v", got, want, validateConnect(e))
+	}
+}
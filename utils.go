@@ -1,5 +1,10 @@
 package xconnect
 
+import (
+	"fmt"
+	"strconv"
+)
+
 type finder interface {
 	find(keys []string) (interface{}, bool)
 }
@@ -11,31 +16,49 @@ func findInMap(path []string, tree map[string]interface{}) (interface{}, bool) {
 	if len(path) == 0 {
 		return nil, false
 	}
-	if len(path) == 1 {
-		f, ok := tree[path[0]]
-		if !ok {
-			return nil, false
-		}
-		return f, true
-	}
-	// > 1
 	f, ok := tree[path[0]]
 	if !ok {
 		return nil, false
 	}
-	mi, ok := f.(map[interface{}]interface{})
-	if !ok {
-		return nil, false
+	return findInValue(path[1:], f)
+}
+
+// findInValue walks maps and lists ; a key for a list must be its index.
+func findInValue(path []string, v interface{}) (interface{}, bool) {
+	if len(path) == 0 {
+		return v, true
 	}
-	// do the copy
-	m := map[string]interface{}{}
-	for k, v := range mi {
-		sk, ok := k.(string)
-		if ok {
-			m[sk] = v
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return findInMap(path, t)
+	case map[interface{}]interface{}:
+		if f, ok := t[path[0]]; ok {
+			return findInValue(path[1:], f)
+		}
+		// keys that are not strings, e.g. numbers
+		for k, f := range t {
+			if fmt.Sprint(k) == path[0] {
+				return findInValue(path[1:], f)
+			}
+		}
+	case []interface{}:
+		if i, ok := listIndex(path[0], len(t)); ok {
+			return findInValue(path[1:], t[i])
+		}
+	case []string:
+		if i, ok := listIndex(path[0], len(t)); ok {
+			return findInValue(path[1:], t[i])
 		}
 	}
-	return findInMap(path[1:], m)
+	return nil, false
+}
+
+func listIndex(key string, size int) (int, bool) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || i >= size {
+		return 0, false
+	}
+	return i, true
 }
 
 func copy(src map[string]interface{}) map[string]interface{} {
@@ -45,3 +68,28 @@ func copy(src map[string]interface{}) map[string]interface{} {
 	}
 	return dst
 }
+
+// normalizeKeys converts all map[interface{}]interface{} (as produced by YAML) into map[string]interface{}.
+func normalizeKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, each := range t {
+			m[fmt.Sprint(k)] = normalizeKeys(each)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, each := range t {
+			m[k] = normalizeKeys(each)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, each := range t {
+			a[i] = normalizeKeys(each)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,158 @@
+package xconnect
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity tells how serious a Finding is.
+type Severity string
+
+const (
+	// SeverityError is used for findings that make a document invalid.
+	SeverityError Severity = "error"
+	// SeverityWarning is used for findings that are suspicious but allowed.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is the result of a single validation check.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Path is the slash path of the offending value, e.g. xconnect/connect/db/port .
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Position is the source position of the value, or of its nearest parent, if known.
+	Position Position `json:"position"`
+}
+
+// String returns a human readable representation.
+func (f Finding) String() string {
+	if f.Position.IsZero() {
+		return fmt.Sprintf("%s: %s: %s", f.Severity, f.Path, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", f.Position, f.Severity, f.Path, f.Message)
+}
+
+// HasErrors returns true if any of the findings has SeverityError.
+func HasErrors(findings []Finding) bool {
+	for _, each := range findings {
+		if each.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the document and returns all findings. An empty list means valid.
+func (d Document) Validate() []Finding {
+	return d.XConnect.validate("xconnect")
+}
+
+// Validate checks the xconnect section and returns all findings. An empty list means valid.
+func (x XConnect) Validate() []Finding {
+	return x.validate("xconnect")
+}
+
+func (x XConnect) validate(prefix string) (list []Finding) {
+	defer func() { x.positions.locate(list) }()
+	add := func(s Severity, path, format string, args ...interface{}) {
+		list = append(list, Finding{Severity: s, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if len(x.Meta.Name) == 0 {
+		add(SeverityError, prefix+"/meta/name", "missing name")
+	}
+	for _, k := range sortedListenKeys(x.Listen) {
+		e := x.Listen[k]
+		path := fmt.Sprintf("%s/listen/%s", prefix, k)
+		validateProtocol(e.Protocol, path, add)
+		validatePort(e.Port, path, add)
+		validateSecurePort(e.Protocol, e.Secure, e.Port, path, add)
+		validateURLOrHost(e.URL, e.Host, e.Port, path, add)
+		validateTLS(e.TLS, e.EffectiveSecure(), true, path, add)
+	}
+	for _, k := range sortedConnectKeys(x.Connect) {
+		e := x.Connect[k]
+		path := fmt.Sprintf("%s/connect/%s", prefix, k)
+		validateProtocol(e.Protocol, path, add)
+		validatePort(e.Port, path, add)
+		validateSecurePort(e.Protocol, e.Secure, e.Port, path, add)
+		validateURLOrHost(e.URL, e.Host, e.Port, path, add)
+		validateTLS(e.TLS, e.EffectiveSecure(), false, path, add)
+		validateTuning(e, path, add)
+		if len(e.URL) == 0 && len(e.Host) == 0 && len(e.Kind) == 0 && len(e.Resource) == 0 {
+			add(SeverityError, path, "missing url, host or kind/resource")
+		}
+	}
+	return
+}
+
+type addFinding func(s Severity, path, format string, args ...interface{})
+
+func validateProtocol(protocol, path string, add addFinding) {
+	if len(protocol) == 0 {
+		return
+	}
+	if protocol == "https" {
+		add(SeverityError, path+"/protocol", "https is not a protocol, use http with secure: true")
+		return
+	}
+	p, ok := LookupProtocol(protocol)
+	if !ok {
+		add(SeverityError, path+"/protocol", "unknown protocol [%s], expected one of %v", protocol, ProtocolNames())
+		return
+	}
+	if p.Name != protocol {
+		add(SeverityWarning, path+"/protocol", "use protocol [%s] instead of [%s]", p.Name, protocol)
+	}
+}
+
+// validateSecurePort detects a port that does not match the (default) secure value of the protocol.
+func validateSecurePort(protocol string, secure *bool, port *int, path string, add addFinding) {
+	p, ok := LookupProtocol(protocol)
+	if !ok || port == nil || p.Port == p.SecurePort {
+		return
+	}
+	isSecure := effectiveSecure(protocol, secure, "")
+	if !isSecure && *port == p.SecurePort {
+		add(SeverityWarning, path+"/port", "port [%d] is the secure port of [%s] but secure is not true", *port, p.Name)
+	}
+	if isSecure && *port == p.Port {
+		add(SeverityWarning, path+"/port", "port [%d] is the insecure port of [%s] but secure is true", *port, p.Name)
+	}
+}
+
+func validatePort(port *int, path string, add addFinding) {
+	if port == nil {
+		return
+	}
+	if *port < 1 || *port > 65535 {
+		add(SeverityError, path+"/port", "port [%d] out of range [1..65535]", *port)
+	}
+}
+
+func validateURLOrHost(url, host string, port *int, path string, add addFinding) {
+	if len(url) == 0 {
+		return
+	}
+	if len(host) != 0 || port != nil {
+		add(SeverityWarning, path, "url should not be combined with host or port")
+	}
+}
+
+// sortedListenKeys returns the keys of the listen entries in sorted order.
+func sortedListenKeys(m map[string]ListenEntry) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// sortedConnectKeys returns the keys of the connect entries in sorted order.
+func sortedConnectKeys(m map[string]ConnectEntry) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
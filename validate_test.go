@@ -0,0 +1,78 @@
+package xconnect
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestValidateSpec(t *testing.T) {
+	doc, err := LoadConfig("spec-xconnect.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := doc.Validate()
+	// the spec shows both url and host for a connect entry
+	if got, want := len(list), 1; got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	if got, want := list[0].Path, "xconnect/connect/<id>"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := HasErrors(list), false; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestValidateFindings(t *testing.T) {
+	cfg := `
+xconnect:
+  listen:
+    api:
+      protocol: https
+      port: 70000
+  connect:
+    db:
+      protocol: jbdc
+      url: jdbc:postgresql://localhost:5432/postgres
+      port: 5432
+    nothing:
+      protocol: tcp
+    misspelled:
+      hots: db
+`
+	var doc Document
+	if err := yaml.Unmarshal([]byte(cfg), &doc); err != nil {
+		t.Fatal(err)
+	}
+	list := doc.Validate()
+	paths := []string{}
+	for _, each := range list {
+		paths = append(paths, each.Path)
+	}
+	want := []string{
+		"xconnect/meta/name",
+		"xconnect/listen/api/protocol",
+		"xconnect/listen/api/port",
+		"xconnect/listen/api/tls-config",
+		"xconnect/connect/db/protocol",
+		"xconnect/connect/db",
+		"xconnect/connect/misspelled",
+		"xconnect/connect/nothing",
+	}
+	if got, want := len(paths), len(want); got != want {
+		t.Fatalf("got [%v] want [%v]:%v", got, want, list)
+	}
+	for i := range want {
+		if got, want := paths[i], want[i]; got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+	if got, want := HasErrors(list), true; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// an entry with only a misspelled field has no address
+	if got, want := list[6].Severity, SeverityError; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+package xconnect
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWatchInterval is the time between two checks of the files of a Watcher.
+const defaultWatchInterval = 2 * time.Second
+
+// Watcher keeps the document of a file, and its overlays, up to date while the files change.
+// Files are compared by content so a replaced file, or a swapped symlink of a mounted Kubernetes ConfigMap, is detected.
+// Changed content that cannot be loaded is rejected and the last good document is kept.
+// Included files are read again on each change of the watched files but are not watched themselves.
+type Watcher struct {
+	// Interval is the time between two checks of the files ; zero means 2 seconds.
+	Interval time.Duration
+	// OnError, if set, is called when changed content is rejected ; once for each rejected content.
+	OnError func(error)
+
+	load        func() (Document, error)
+	files       []string
+	current     atomic.Value // *Document
+	mutex       sync.Mutex   // serializes loading and guards fingerprint and subscriptions
+	fingerprint string
+	// rejected is the fingerprint of content that could not be loaded ; it is reported once.
+	rejected    string
+	nextID      int
+	subscribers []subscriber
+}
+
+// subscriber is a callback for changes of the value at a path.
+type subscriber struct {
+	id       int
+	keys     []string
+	callback func(oldValue, newValue interface{})
+}
+
+// WatchConfig returns a Watcher for a file and its overlays ; see LoadConfig.
+func WatchConfig(filename string, overlays ...string) (*Watcher, error) {
+	return NewLoader().Watch(filename, overlays...)
+}
+
+// WatchEnvConfig returns a Watcher like GetConfig does.
+// If the environment value is set then the document is read from it and never changes.
+func WatchEnvConfig(envKey string, filename string, overlays ...string) (*Watcher, error) {
+	return NewLoader().WatchEnv(envKey, filename, overlays...)
+}
+
+// Watch returns a Watcher that uses this loader to load a file and its overlays.
+func (l *Loader) Watch(filename string, overlays ...string) (*Watcher, error) {
+	w := &Watcher{
+		load:  func() (Document, error) { return l.Load(filename, overlays...) },
+		files: append([]string{filename}, overlays...),
+	}
+	if err := w.init(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WatchEnv returns a Watcher that uses this loader like Get does.
+func (l *Loader) WatchEnv(envKey string, filename string, overlays ...string) (*Watcher, error) {
+	if len(os.Getenv(envKey)) == 0 {
+		return l.Watch(filename, overlays...)
+	}
+	w := &Watcher{load: func() (Document, error) { return l.Get(envKey, filename, overlays...) }}
+	if err := w.init(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// init loads the first document ; it must be valid.
+func (w *Watcher) init() error {
+	fp, err := w.readFingerprint()
+	if err != nil {
+		return err
+	}
+	doc, err := w.load()
+	if err != nil {
+		return err
+	}
+	w.fingerprint = fp
+	w.current.Store(&doc)
+	return nil
+}
+
+// Document returns the current document. It must not be modified.
+func (w *Watcher) Document() *Document {
+	return w.current.Load().(*Document)
+}
+
+// Subscribe registers a callback for changes of the value at a slash path, e.g. xconnect/connect/some-db .
+// An empty path is the whole document. The value is nil if the path is not found.
+// Callbacks are called after the new document is available and without holding a lock, such that a callback can
+// call Subscribe, Reload or the returned function. Run calls them one at a time. The returned function removes the subscription.
+// Changes of only source positions, e.g. by a comment, are not reported.
+func (w *Watcher) Subscribe(path string, callback func(oldValue, newValue interface{})) func() {
+	var keys []string
+	if len(path) > 0 {
+		keys = splitPath(path)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.nextID++
+	id := w.nextID
+	w.subscribers = append(w.subscribers, subscriber{id: id, keys: keys, callback: callback})
+	return func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		for i, each := range w.subscribers {
+			if each.id == id {
+				w.subscribers = append(w.subscribers[:i:i], w.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Run checks the files for changes every Interval until the context is done.
+func (w *Watcher) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Reload(); err != nil && w.OnError != nil {
+				w.OnError(err)
+			}
+		}
+	}
+}
+
+// Reload loads the document if the files have changed and returns true if the document was replaced.
+// If the new content cannot be loaded then the current document is kept and the error is returned ;
+// the same content is not loaded again until the files change.
+func (w *Watcher) Reload() (bool, error) {
+	w.mutex.Lock()
+	fp, err := w.readFingerprint()
+	if err != nil {
+		w.mutex.Unlock()
+		return false, err
+	}
+	if fp == w.fingerprint {
+		// back to the content of the current document
+		w.rejected = ""
+		w.mutex.Unlock()
+		return false, nil
+	}
+	if fp == w.rejected {
+		w.mutex.Unlock()
+		return false, nil
+	}
+	doc, err := w.load()
+	if err != nil {
+		w.rejected = fp
+		w.mutex.Unlock()
+		return false, fmt.Errorf("unable to reload, keeping the current document:%v", err)
+	}
+	w.fingerprint, w.rejected = fp, ""
+	old := w.Document()
+	w.current.Store(&doc)
+	subscribers := append([]subscriber{}, w.subscribers...)
+	w.mutex.Unlock()
+	for _, each := range subscribers {
+		oldValue, _ := old.find(each.keys)
+		newValue, _ := doc.find(each.keys)
+		if !reflect.DeepEqual(withoutPositions(oldValue), withoutPositions(newValue)) {
+			each.callback(oldValue, newValue)
+		}
+	}
+	return true, nil
+}
+
+// withoutPositions returns a copy of a document or section value without its source positions.
+func withoutPositions(v interface{}) interface{} {
+	switch t := v.(type) {
+	case Document:
+		t.XConnect.positions = nil
+		return t
+	case XConnect:
+		t.positions = nil
+		return t
+	}
+	return v
+}
+
+// readFingerprint returns a hash of the contents of all watched files.
+func (w *Watcher) readFingerprint() (string, error) {
+	h := sha256.New()
+	for _, each := range w.files {
+		// ReadFile follows symlinks, e.g. the ..data link of a ConfigMap volume
+		content, err := ioutil.ReadFile(each)
+		if err != nil {
+			return "", fmt.Errorf("unable to read:%v", err)
+		}
+		sum := sha256.Sum256(content)
+		h.Write(sum[:])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
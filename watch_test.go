@@ -0,0 +1,151 @@
+package xconnect
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const watchYAML = `
+xconnect:
+  connect:
+    some-db:
+      url: postgres://db:5432/accounts
+    cache:
+      host: cache
+`
+
+func TestWatcherReload(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"xconnect.yaml": watchYAML})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "xconnect.yaml")
+	w, err := WatchConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var oldURL, newURL interface{}
+	calls := 0
+	w.Subscribe("xconnect/connect/some-db/url", func(o, n interface{}) {
+		oldURL, newURL = o, n
+		calls++
+	})
+	w.Subscribe("xconnect/connect/cache", func(o, n interface{}) {
+		t.Error("cache is not changed")
+	})
+	// unchanged
+	if changed, err := w.Reload(); changed || err != nil {
+		t.Errorf("got [%v,%v] want [false,<nil>]", changed, err)
+	}
+	ioutil.WriteFile(file, []byte(`
+xconnect:
+  connect:
+    some-db:
+      url: postgres://other-db:5432/accounts
+    cache:
+      host: cache
+`), os.ModePerm)
+	if changed, err := w.Reload(); !changed || err != nil {
+		t.Errorf("got [%v,%v] want [true,<nil>]", changed, err)
+	}
+	if got, want := calls, 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := oldURL, "postgres://db:5432/accounts"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := newURL, "postgres://other-db:5432/accounts"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// invalid content is rejected
+	ioutil.WriteFile(file, []byte("xconnect: ["), os.ModePerm)
+	if changed, err := w.Reload(); changed || err == nil {
+		t.Errorf("got [%v,%v] want [false,error]", changed, err)
+	}
+	if got, want := w.Document().MustString("xconnect/connect/some-db/url"), "postgres://other-db:5432/accounts"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// rejected content is reported once
+	if changed, err := w.Reload(); changed || err != nil {
+		t.Errorf("got [%v,%v] want [false,<nil>]", changed, err)
+	}
+	ioutil.WriteFile(file, []byte("xconnect: {"), os.ModePerm)
+	if changed, err := w.Reload(); changed || err == nil {
+		t.Errorf("got [%v,%v] want [false,error]", changed, err)
+	}
+}
+
+func TestWatcherCallbackSubscribes(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{"xconnect.yaml": watchYAML})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "xconnect.yaml")
+	w, err := WatchConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel := w.Subscribe("xconnect", func(o, n interface{}) {
+		t.Error("only a comment is changed")
+	})
+	// a comment changes positions only
+	ioutil.WriteFile(file, []byte("# comment\n"+watchYAML), os.ModePerm)
+	if changed, err := w.Reload(); !changed || err != nil {
+		t.Errorf("got [%v,%v] want [true,<nil>]", changed, err)
+	}
+	cancel()
+	calls := 0
+	var unsubscribe func()
+	unsubscribe = w.Subscribe("xconnect/connect/some-db/url", func(o, n interface{}) {
+		calls++
+		// must not deadlock
+		unsubscribe()
+		w.Subscribe("xconnect/connect/cache", func(o, n interface{}) {})
+		w.Reload()
+	})
+	ioutil.WriteFile(file, []byte(strings.Replace(watchYAML, "//db", "//other-db", 1)), os.ModePerm)
+	w.Reload()
+	ioutil.WriteFile(file, []byte(watchYAML), os.ModePerm)
+	w.Reload()
+	if got, want := calls, 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+// TestWatcherSymlinkSwap mimics the update of a mounted ConfigMap: the ..data symlink is replaced by one to a new directory.
+func TestWatcherSymlinkSwap(t *testing.T) {
+	dir := writeTempFiles(t, map[string]string{
+		"v1/xconnect.yaml": watchYAML,
+		"v2/xconnect.yaml": "xconnect:\n  connect:\n    cache:\n      host: cache-2\n",
+	})
+	defer os.RemoveAll(dir)
+	if err := os.Symlink("v1", filepath.Join(dir, "..data")); err != nil {
+		t.Skip(err)
+	}
+	os.Symlink(filepath.Join("..data", "xconnect.yaml"), filepath.Join(dir, "xconnect.yaml"))
+	w, err := WatchConfig(filepath.Join(dir, "xconnect.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Interval = 10 * time.Millisecond
+	changes := make(chan interface{}, 1)
+	w.Subscribe("xconnect/connect/some-db", func(o, n interface{}) { changes <- n })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	os.Symlink("v2", filepath.Join(dir, "..data_tmp"))
+	os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))
+	select {
+	case n := <-changes:
+		if n != nil {
+			t.Errorf("got [%v] want [<nil>]", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change detected")
+	}
+	if got, want := w.Document().MustString("xconnect/connect/cache/host"), "cache-2"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
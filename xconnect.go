@@ -3,8 +3,7 @@ package xconnect
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
-	"strings"
+	"path/filepath"
 
 	"gopkg.in/yaml.v2"
 )
@@ -17,12 +16,14 @@ type XConnect struct {
 	Meta        MetaProperties          `yaml:"meta" json:"meta"`
 	Listen      map[string]ListenEntry  `yaml:"listen" json:"listen"`
 	Connect     map[string]ConnectEntry `yaml:"connect" json:"connect"`
-	ExtraFields map[string]interface{}  `yaml:"-,inline"`
+	ExtraFields map[string]interface{}  `yaml:"-,inline" json:"-"`
+	// positions has the source positions of the document, by full slash path
+	positions positionIndex
 }
 
 func (x XConnect) find(keys []string) (interface{}, bool) {
 	if len(keys) == 0 {
-		return nil, false
+		return x, true
 	}
 	switch keys[0] {
 	case "meta":
@@ -30,7 +31,7 @@ func (x XConnect) find(keys []string) (interface{}, bool) {
 	case "listen":
 		subkeys := keys[1:]
 		if len(subkeys) == 0 {
-			return nil, false
+			return x.Listen, x.Listen != nil
 		}
 		for k, each := range x.Listen {
 			if subkeys[0] == k {
@@ -43,7 +44,7 @@ func (x XConnect) find(keys []string) (interface{}, bool) {
 	case "connect":
 		subkeys := keys[1:]
 		if len(subkeys) == 0 {
-			return nil, false
+			return x.Connect, x.Connect != nil
 		}
 		for k, each := range x.Connect {
 			if subkeys[0] == k {
@@ -65,13 +66,13 @@ type MetaProperties struct {
 	// Operational expenditure, or owner
 	Opex        string                 `yaml:"opex,omitempty" json:"opex,omitempty"`
 	Labels      []string               `yaml:"tags,omitempty" json:"tags,omitempty"`
-	ExtraFields map[string]interface{} `yaml:"-,inline"`
+	ExtraFields map[string]interface{} `yaml:"-,inline" json:"-"`
 	Kind        string                 `yaml:"kind,omitempty" json:"kind,omitempty"`
 }
 
 func (m MetaProperties) find(keys []string) (interface{}, bool) {
 	if len(keys) == 0 {
-		return nil, false
+		return m, true
 	}
 	switch keys[0] {
 	case "name":
@@ -82,6 +83,8 @@ func (m MetaProperties) find(keys []string) (interface{}, bool) {
 		return m.Opex, true
 	case "kind":
 		return m.Kind, true
+	case "tags":
+		return findInValue(keys[1:], m.Labels)
 	default:
 		return findInMap(keys, m.ExtraFields)
 	}
@@ -89,8 +92,8 @@ func (m MetaProperties) find(keys []string) (interface{}, bool) {
 
 // Document is the root YAML element
 type Document struct {
-	XConnect    XConnect               `yaml:"xconnect"`
-	ExtraFields map[string]interface{} `yaml:"-,inline"`
+	XConnect    XConnect               `yaml:"xconnect" json:"xconnect"`
+	ExtraFields map[string]interface{} `yaml:"-,inline" json:"-"`
 }
 
 // MustString same as FindString but panics if not found. E.g xconnect/connect/db/url .
@@ -108,19 +111,27 @@ func (d Document) FindString(path string) (string, error) {
 }
 
 // FindString returns a string for a given slash path, e.g xconnect/connect/db/url .
+// Numbers and booleans are converted to their string representation.
 func FindString(f finder, path string) (string, error) {
-	keys := strings.Split(path, extraPathSeparator)
-	v, ok := f.find(keys)
-	if !ok {
-		return "", fmt.Errorf("unable to find string at [%s]", path)
+	v, err := lookup(f, path, "string")
+	if err != nil {
+		return "", err
 	}
-	if s, ok := v.(string); !ok {
-		return "", fmt.Errorf("warn: xconnect, value is not a string, but a %T for path %s\n", v, path)
+	if s, ok := toString(v); !ok {
+		return "", mismatch(f, path, "string", v)
 	} else {
 		return s, nil
 	}
 }
 
+// StringOr returns the string for a given slash path or the default value if not found or not convertible.
+func (d Document) StringOr(path string, defaultValue string) string {
+	if v, err := d.FindString(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
 // MustBool same as FindBool but panics if not found. E.g xconnect/listen/api/secure
 func (d Document) MustBool(path string) bool {
 	if v, err := d.FindBool(path); err != nil {
@@ -135,17 +146,26 @@ func (d Document) FindBool(path string) (bool, error) {
 	return FindBool(d, path)
 }
 
+// FindBool returns a bool for a given slash path.
+// Strings such as "true" and "false" are converted.
 func FindBool(f finder, path string) (bool, error) {
-	keys := strings.Split(path, extraPathSeparator)
-	v, ok := f.find(keys)
-	if !ok {
-		return false, fmt.Errorf("unable to find bool at [%s]", path)
+	v, err := lookup(f, path, "bool")
+	if err != nil {
+		return false, err
 	}
-	if s, ok := v.(bool); !ok {
-		return false, fmt.Errorf("warn: xconnect, value is not a bool, but a %T for path %s\n", v, path)
+	if b, ok := toBool(v); !ok {
+		return false, mismatch(f, path, "bool", v)
 	} else {
-		return s, nil
+		return b, nil
+	}
+}
+
+// BoolOr returns the bool for a given slash path or the default value if not found or not convertible.
+func (d Document) BoolOr(path string, defaultValue bool) bool {
+	if v, err := d.FindBool(path); err == nil {
+		return v
 	}
+	return defaultValue
 }
 
 // MustInt same as FindInt but panics if not found. E.g xconnect/listen/api/port
@@ -163,22 +183,40 @@ func (d Document) FindInt(path string) (int, error) {
 }
 
 // FindInt returns a integer for a given slash path.
+// Other number types without fraction and strings such as "8080" are converted.
 func FindInt(f finder, path string) (int, error) {
-	keys := strings.Split(path, extraPathSeparator)
-	v, ok := f.find(keys)
-	if !ok {
-		return 0, fmt.Errorf("unable to find int at [%s]", path)
+	v, err := lookup(f, path, "int")
+	if err != nil {
+		return 0, err
 	}
-	if s, ok := v.(int); !ok {
-		return 0, fmt.Errorf("warn: xconnect, value is not a int, but a %T for path %s\n", v, path)
+	if i, ok := toInt(v); !ok {
+		return 0, mismatch(f, path, "int", v)
 	} else {
-		return s, nil
+		return i, nil
 	}
 }
 
+// IntOr returns the integer for a given slash path or the default value if not found or not convertible.
+func (d Document) IntOr(path string, defaultValue int) int {
+	if v, err := d.FindInt(path); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// lookup returns the value for a given slash path or a *NotFoundError.
+func lookup(f finder, path string, typeName string) (interface{}, error) {
+	keys := splitPath(path)
+	v, ok := f.find(keys)
+	if !ok {
+		return nil, notFound(f, path, keys, typeName)
+	}
+	return v, nil
+}
+
 func (d Document) find(keys []string) (interface{}, bool) {
 	if len(keys) == 0 {
-		return nil, false
+		return d, true
 	}
 	switch keys[0] {
 	case "xconnect":
@@ -190,29 +228,68 @@ func (d Document) find(keys []string) (interface{}, bool) {
 
 // GetConfig will first check the environment value at {envKey} to find the source of the confguration.
 // If the environment value is not available (empty) then try reading the filename to get the configuration.
-func GetConfig(envKey string, filename string) (Document, error) {
-	content := os.Getenv(envKey)
-	if len(content) == 0 {
-		return LoadConfig(filename)
+// Overlay files, if any, are merged on top of it ; see LoadConfig.
+func GetConfig(envKey string, filename string, overlays ...string) (Document, error) {
+	return NewLoader().Get(envKey, filename, overlays...)
+}
+
+// LoadConfig returns the document containing the xconnect section.
+// Overlay files, if any, are merged on top of it in the given order, e.g. xconnect.yaml + xconnect.prod.yaml .
+// References such as ${ENV_VAR}, ${ENV_VAR:default} and ${xconnect.connect.some-db.url} are resolved after merging.
+// Secret references in extra fields of listen and connect entries are resolved by the file and env resolvers ;
+// a string value that starts with env:// or secret://file/ is therefore read from the environment or a file.
+// Use a Loader with SkipSecrets to keep such values as they are.
+func LoadConfig(filename string, overlays ...string) (Document, error) {
+	return NewLoader().Load(filename, overlays...)
+}
+
+// readTree reads a YAML file into a generic tree and resolves all includes.
+// It also returns the positions of all keys and list elements.
+func readTree(filename string) (map[interface{}]interface{}, positionIndex, error) {
+	tree, idx, err := parseFile(filename)
+	if err != nil {
+		return nil, nil, err
 	}
-	var doc Document
-	err := yaml.Unmarshal([]byte(content), &doc)
+	abs, err := filepath.Abs(filename)
 	if err != nil {
-		return Document{}, fmt.Errorf("unable to unmarshal YAML:%v", err)
+		return nil, nil, err
 	}
-	return doc, nil
+	tree, err = includeAll(tree, filepath.Dir(abs), []string{abs + "#"}, idx)
+	return tree, idx, err
 }
 
-// LoadConfig returns the document containing the xconnect section.
-func LoadConfig(filename string) (Document, error) {
+// parseFile reads a YAML file into a generic tree and the positions of its keys and list elements.
+func parseFile(filename string) (map[interface{}]interface{}, positionIndex, error) {
 	content, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return Document{}, fmt.Errorf("unable to read:%v", err)
+		return nil, nil, fmt.Errorf("unable to read:%v", err)
+	}
+	tree, err := parseTree(content, filename)
+	if err != nil {
+		return nil, nil, err
 	}
+	return tree, parsePositions(content, filename), nil
+}
+
+// parseTree unmarshals YAML content, from a file with the given name, into a generic tree.
+func parseTree(content []byte, filename string) (map[interface{}]interface{}, error) {
+	tree := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return nil, parseError(filename, err)
+	}
+	return tree, nil
+}
+
+// decodeTree converts a generic YAML tree into a Document.
+// Errors, such as a port that is not a number, refer to the positions in the index.
+func decodeTree(tree map[interface{}]interface{}, idx positionIndex) (Document, error) {
 	var doc Document
-	err = yaml.Unmarshal(content, &doc)
+	data, err := yaml.Marshal(tree)
 	if err != nil {
-		return Document{}, fmt.Errorf("unable to unmarshal YAML:%v", err)
+		return doc, fmt.Errorf("unable to marshal YAML:%v", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Document{}, idx.decodeError(data, err)
 	}
 	return doc, nil
 }
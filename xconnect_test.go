@@ -105,6 +105,31 @@ func TestSpec(t *testing.T) {
 	if got, want := idc.Host, "there.com"; got != want {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
+	idl := x.Listen["<id>"]
+	if got, want := idl.Kind, "gcp.pubsub"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := idl.Resource, "account_topic"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(idl.ExtraFields), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestListenResourceID(t *testing.T) {
+	port := 7070
+	e := ListenEntry{Kind: "gcp.pubsub", Resource: "account_topic"}
+	if got, want := e.ResourceID(), "gcp.pubsub:account_topic"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	e.Host, e.Port = "account-service.net", &port
+	if got, want := e.ResourceID(), "account-service.net:7070"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := (ListenEntry{}).NetworkID(), ""; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
 }
 
 func TestDumpSpec(t *testing.T) {